@@ -57,3 +57,211 @@ CREATE TABLE delivery_prices AS
 SELECT DISTINCT f.aircraft_code, f.departure_airport, f.arrival_airport, tf.fare_conditions, tf.amount price
 FROM flights f
 INNER JOIN ticket_flights tf ON tf.flight_id = f.flight_id;
+
+
+CREATE TABLE airport_connection_times (
+    airport_code char(3) PRIMARY KEY REFERENCES airports_data(airport_code),
+    min_connection_minutes integer NOT NULL CHECK (min_connection_minutes >= 0)
+);
+
+
+ALTER TABLE books
+ADD COLUMN status varchar(20) NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'cancelled')),
+ADD COLUMN cancelled_at timestamptz,
+ADD COLUMN refund_amount numeric(10, 2);
+
+
+ALTER TABLE books
+ADD COLUMN request_fingerprint char(64);
+
+
+ALTER TABLE books
+ADD COLUMN passenger_no smallint NOT NULL DEFAULT 1,
+ADD COLUMN document_number varchar(20),
+ADD COLUMN date_of_birth date,
+ADD COLUMN contact text;
+
+
+-- Бронирование: заголовок по GUID, сегменты (GUID, flight_id), пассажиры и билеты.
+CREATE TABLE booking_headers (
+    guid text PRIMARY KEY,
+    passanger text NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'active',
+    total_amount numeric(10, 2) NOT NULL DEFAULT 0,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    request_fingerprint char(64)
+);
+
+CREATE TABLE booking_segments (
+    guid text NOT NULL REFERENCES booking_headers(guid),
+    flight_id integer NOT NULL REFERENCES flights(flight_id),
+    fare_conditions varchar(10) NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'cancelled')),
+    cancelled_at timestamptz,
+    refund_amount numeric(10, 2),
+    PRIMARY KEY (guid, flight_id)
+);
+
+CREATE TABLE booking_passengers (
+    guid text NOT NULL REFERENCES booking_headers(guid),
+    passenger_no smallint NOT NULL,
+    name text NOT NULL,
+    document_number varchar(20),
+    date_of_birth date,
+    contact text,
+    PRIMARY KEY (guid, passenger_no)
+);
+
+INSERT INTO booking_headers (guid, passanger, status, total_amount, created_at, request_fingerprint)
+SELECT b.guid,
+       (array_agg(b.passanger ORDER BY b.passenger_no))[1],
+       CASE WHEN bool_and(b.status = 'cancelled') THEN 'cancelled' ELSE 'active' END,
+       COALESCE(SUM(tf.amount), 0),
+       now(),
+       MAX(b.request_fingerprint)
+FROM books b
+LEFT JOIN ticket_flights tf ON tf.ticket_no = b.ticket_no AND tf.flight_id = b.flight_id
+GROUP BY b.guid;
+
+INSERT INTO booking_segments (guid, flight_id, fare_conditions, status, cancelled_at, refund_amount)
+SELECT guid, flight_id, MIN(fare_conditions),
+       CASE WHEN bool_and(status = 'cancelled') THEN 'cancelled' ELSE 'active' END,
+       MAX(cancelled_at),
+       SUM(refund_amount)
+FROM books
+GROUP BY guid, flight_id;
+
+INSERT INTO booking_passengers (guid, passenger_no, name, document_number, date_of_birth, contact)
+SELECT DISTINCT ON (guid, passenger_no) guid, passenger_no, passanger, document_number, date_of_birth, contact
+FROM books
+ORDER BY guid, passenger_no;
+
+-- books остаётся таблицей билетов бронирования: один билет на пассажира на рейс.
+ALTER TABLE books DROP CONSTRAINT IF EXISTS books_pkey;
+
+ALTER TABLE books
+DROP COLUMN fare_conditions,
+DROP COLUMN passanger,
+DROP COLUMN status,
+DROP COLUMN cancelled_at,
+DROP COLUMN refund_amount,
+DROP COLUMN request_fingerprint,
+DROP COLUMN document_number,
+DROP COLUMN date_of_birth,
+DROP COLUMN contact;
+
+ALTER TABLE books
+ADD PRIMARY KEY (ticket_no),
+ADD FOREIGN KEY (guid, flight_id) REFERENCES booking_segments(guid, flight_id),
+ADD FOREIGN KEY (guid, passenger_no) REFERENCES booking_passengers(guid, passenger_no);
+
+
+CREATE SEQUENCE ticket_no_seq MINVALUE 1 MAXVALUE 999999999 NO CYCLE;
+
+
+CREATE TABLE seat_holds (
+    guid text NOT NULL,
+    flight_id integer NOT NULL REFERENCES flights(flight_id),
+    fare_conditions varchar(10) NOT NULL,
+    seats smallint NOT NULL CHECK (seats > 0),
+    status varchar(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'confirmed', 'expired')),
+    created_at timestamptz NOT NULL DEFAULT now(),
+    expires_at timestamptz NOT NULL,
+    PRIMARY KEY (guid, flight_id)
+);
+
+CREATE INDEX seat_holds_active_idx ON seat_holds (flight_id, fare_conditions)
+WHERE status = 'active';
+
+-- Кресла, закреплённые за удержанием: на схеме мест они показываются как held, и регистрация
+-- их не выдаёт, пока удержание активно и не истекло.
+CREATE TABLE seat_hold_seats (
+    guid text NOT NULL,
+    flight_id integer NOT NULL,
+    seat_no varchar(4) NOT NULL,
+    PRIMARY KEY (guid, flight_id, seat_no),
+    FOREIGN KEY (guid, flight_id) REFERENCES seat_holds(guid, flight_id) ON DELETE CASCADE
+);
+
+CREATE INDEX seat_hold_seats_flight_idx ON seat_hold_seats (flight_id);
+
+
+ALTER TABLE booking_segments
+DROP CONSTRAINT booking_segments_status_check,
+ADD CONSTRAINT booking_segments_status_check
+    CHECK (status IN ('active', 'cancelled', 'changed'));
+
+CREATE TABLE booking_changes (
+    change_id serial PRIMARY KEY,
+    guid text NOT NULL REFERENCES booking_headers(guid),
+    old_flight_id integer NOT NULL REFERENCES flights(flight_id),
+    new_flight_id integer NOT NULL REFERENCES flights(flight_id),
+    old_fare_conditions varchar(10) NOT NULL,
+    new_fare_conditions varchar(10) NOT NULL,
+    passengers smallint NOT NULL,
+    old_amount numeric(10, 2) NOT NULL,
+    new_amount numeric(10, 2) NOT NULL,
+    fare_difference numeric(10, 2) NOT NULL,
+    voided_boarding_passes smallint NOT NULL DEFAULT 0,
+    changed_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX booking_changes_guid_idx ON booking_changes (guid);
+
+
+-- Оплата: бронирование проходит pending → paid → ticketed, старые остаются active.
+ALTER TABLE booking_headers
+ALTER COLUMN status SET DEFAULT 'pending',
+ADD COLUMN payment_id text;
+
+
+-- Платежи бронирования: первичная оплата и доплаты при изменении. Возвраты не превышают
+-- списанного; refund_pending — возвраты, записанные при отмене или изменении, но ещё не проведённые
+-- провайдером (он вызывается после фиксации транзакции); оставшиеся после запроса возвращаются вручную.
+CREATE TABLE booking_payments (
+    payment_id text PRIMARY KEY,
+    guid text NOT NULL REFERENCES booking_headers(guid),
+    amount numeric(10, 2) NOT NULL CHECK (amount > 0),
+    refunded numeric(10, 2) NOT NULL DEFAULT 0,
+    refund_pending numeric(10, 2) NOT NULL DEFAULT 0,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CHECK (refunded + refund_pending <= amount)
+);
+
+CREATE INDEX booking_payments_guid_idx ON booking_payments (guid);
+
+INSERT INTO booking_payments (payment_id, guid, amount, created_at)
+SELECT payment_id, guid, total_amount, created_at
+FROM booking_headers
+WHERE payment_id IS NOT NULL AND total_amount > 0;
+
+
+-- Короткий код бронирования (PNR) для посадочных талонов. Существующим бронированиям код
+-- подбирается случайно из того же алфавита, что и в newPNR (pnrAlphabet), с повтором при
+-- совпадении; уникальный индекс создаётся заранее, чтобы проверка была быстрой.
+ALTER TABLE booking_headers
+ADD COLUMN pnr char(6),
+ADD CONSTRAINT booking_headers_pnr_key UNIQUE (pnr);
+
+DO $$
+DECLARE
+    alphabet constant text := 'ABCDEFGHJKLMNPQRSTUVWXYZ23456789';
+    b record;
+    code text;
+BEGIN
+    FOR b IN SELECT guid FROM booking_headers WHERE pnr IS NULL LOOP
+        LOOP
+            SELECT string_agg(substr(alphabet, 1 + floor(random() * length(alphabet))::int, 1), '')
+            INTO code
+            FROM generate_series(1, 6);
+            EXIT WHEN NOT EXISTS (SELECT 1 FROM booking_headers WHERE pnr = code);
+        END LOOP;
+        UPDATE booking_headers SET pnr = code WHERE guid = b.guid;
+    END LOOP;
+END
+$$;
+
+ALTER TABLE booking_headers ALTER COLUMN pnr SET NOT NULL;
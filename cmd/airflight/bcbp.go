@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/AntonTsoy/airflight-service/internal/bcbp"
+)
+
+// Коды салона BCBP для классов обслуживания.
+var compartmentCodes = map[string]string{
+	"Business":   "C",
+	"Comfort":    "W",
+	"Economy":    "Y",
+	"EconomySec": "Y",
+}
+
+// boardingPassBCBP собирает строку BCBP посадочного талона. Пассажир и код бронирования (PNR)
+// берутся из бронирования сервиса, а для билетов демо-базы — из таблицы tickets (book_ref).
+func boardingPassBCBP(tx *gorm.DB, ticketNo string, flightID uint, seatNo string, boardingNo int) (string, error) {
+	var row struct {
+		FlightNo           string
+		DepartureAirport   string
+		ArrivalAirport     string
+		ScheduledDeparture time.Time
+		FareConditions     string
+		PassengerName      string
+		BookingRef         string
+	}
+	if err := tx.Raw(`
+        SELECT f.flight_no, f.departure_airport, f.arrival_airport, f.scheduled_departure,
+               tf.fare_conditions,
+               COALESCE(bp.name, t.passenger_name, '') AS passenger_name,
+               COALESCE(h.pnr, t.book_ref, '') AS booking_ref
+        FROM ticket_flights tf
+        JOIN flights f ON f.flight_id = tf.flight_id
+        LEFT JOIN books b ON b.ticket_no = tf.ticket_no
+        LEFT JOIN booking_headers h ON h.guid = b.guid
+        LEFT JOIN booking_passengers bp ON bp.guid = b.guid AND bp.passenger_no = b.passenger_no
+        LEFT JOIN tickets t ON t.ticket_no = tf.ticket_no
+        WHERE tf.ticket_no = ? AND tf.flight_id = ?`, ticketNo, flightID).Scan(&row).Error; err != nil {
+		return "", err
+	}
+	if row.FlightNo == "" {
+		return "", gorm.ErrRecordNotFound
+	}
+
+	zones, err := airportZones()
+	if err != nil {
+		return "", err
+	}
+	departure := localTime(row.ScheduledDeparture, row.DepartureAirport, zones)
+
+	// Номер рейса демо-базы — код перевозчика из двух символов и номер: "PG0402".
+	carrier, number := row.FlightNo, ""
+	if len(row.FlightNo) > 2 {
+		carrier, number = row.FlightNo[:2], row.FlightNo[2:]
+	}
+	return bcbp.Encode(bcbp.BoardingPass{
+		PassengerName:   bcbp.Name(row.PassengerName),
+		ETicket:         true,
+		PNR:             strings.ToUpper(row.BookingRef),
+		From:            row.DepartureAirport,
+		To:              row.ArrivalAirport,
+		Carrier:         carrier,
+		FlightNumber:    number,
+		JulianDate:      departure.YearDay(),
+		Compartment:     compartmentCodes[row.FareConditions],
+		Seat:            seatNo,
+		Sequence:        boardingNo,
+		PassengerStatus: bcbp.StatusCheckedIn,
+	})
+}
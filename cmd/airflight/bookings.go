@@ -0,0 +1,713 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/AntonTsoy/airflight-service/internal/models"
+)
+
+// bookingRecord — бронирование целиком: заголовок, сегменты (рейсы), пассажиры и билеты.
+type bookingRecord struct {
+	Header     models.BookingHeader
+	Segments   []models.BookingSegment
+	Passengers []models.BookingPassenger
+	Books      []models.Book
+}
+
+// findBooking возвращает nil без ошибки, если бронирования с таким GUID нет.
+func findBooking(tx *gorm.DB, guid string) (*bookingRecord, error) {
+	return readBooking(tx, guid, false)
+}
+
+// lockBooking — findBooking с блокировкой заголовка (FOR UPDATE) для отмены и изменения:
+// параллельные запросы к одному бронированию выполняются по очереди, и каждый читает
+// сегменты уже после того, как предыдущий зафиксирован.
+func lockBooking(tx *gorm.DB, guid string) (*bookingRecord, error) {
+	return readBooking(tx, guid, true)
+}
+
+func readBooking(tx *gorm.DB, guid string, lock bool) (*bookingRecord, error) {
+	var record bookingRecord
+	header := tx
+	if lock {
+		header = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	if err := header.Where("guid = ?", guid).First(&record.Header).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := tx.Where("guid = ?", guid).Order("flight_id").Find(&record.Segments).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("guid = ?", guid).Order("passenger_no").Find(&record.Passengers).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("guid = ?", guid).Order("passenger_no, flight_id").Find(&record.Books).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (b *bookingRecord) flightIDs() []uint {
+	ids := make([]uint, 0, len(b.Segments))
+	for _, segment := range b.Segments {
+		ids = append(ids, segment.FlightID)
+	}
+	return ids
+}
+
+func (b *bookingRecord) passengers() []models.Passenger {
+	passengers := make([]models.Passenger, 0, len(b.Passengers))
+	for _, p := range b.Passengers {
+		passenger := models.Passenger{
+			Name:           p.Name,
+			DocumentNumber: p.DocumentNumber,
+			Contact:        p.Contact,
+		}
+		if p.DateOfBirth != nil {
+			passenger.DateOfBirth = p.DateOfBirth.Format(dateLayout)
+		}
+		passengers = append(passengers, passenger)
+	}
+	return passengers
+}
+
+func (b *bookingRecord) segment(flightID uint) *models.BookingSegment {
+	for i := range b.Segments {
+		if b.Segments[i].FlightID == flightID {
+			return &b.Segments[i]
+		}
+	}
+	return nil
+}
+
+const (
+	pnrLength = 6
+	// Без похожих друг на друга символов (0/O, 1/I), чтобы код было легко продиктовать.
+	pnrAlphabet    = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	maxPNRAttempts = 10
+)
+
+// newPNR подбирает случайный код бронирования, ещё не занятый другим бронированием.
+func newPNR(tx *gorm.DB) (string, error) {
+	buf := make([]byte, pnrLength)
+	for range maxPNRAttempts {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for i, b := range buf {
+			buf[i] = pnrAlphabet[int(b)%len(pnrAlphabet)]
+		}
+		pnr := string(buf)
+
+		var taken int64
+		if err := tx.Model(&models.BookingHeader{}).Where("pnr = ?", pnr).Count(&taken).Error; err != nil {
+			return "", err
+		}
+		if taken == 0 {
+			return pnr, nil
+		}
+	}
+	return "", fmt.Errorf("no free PNR after %d attempts", maxPNRAttempts)
+}
+
+// createBooking создаёт заголовок в статусе pending, сегменты и пассажиров.
+// Рейсы должны быть уже заблокированы и проверены lockBookableFlights.
+func createBooking(tx *gorm.DB, guid string, req models.BookingRequest, fingerprint string, prices map[uint]float64, now time.Time) (*bookingRecord, error) {
+	pnr, err := newPNR(tx)
+	if err != nil {
+		return nil, err
+	}
+	record := &bookingRecord{
+		Header: models.BookingHeader{
+			GUID:               guid,
+			PNR:                pnr,
+			Passanger:          req.Passengers[0].Name,
+			Status:             models.BookStatusPending,
+			CreatedAt:          now,
+			RequestFingerprint: fingerprint,
+		},
+	}
+	for _, flightID := range req.FlightIDs {
+		record.Header.TotalAmount += prices[flightID] * float64(len(req.Passengers))
+		record.Segments = append(record.Segments, models.BookingSegment{
+			GUID:           guid,
+			FlightID:       flightID,
+			FareConditions: req.FareConditions,
+			Status:         models.BookStatusActive,
+		})
+	}
+	record.Header.TotalAmount = math.Round(record.Header.TotalAmount*100) / 100
+	for i, passenger := range req.Passengers {
+		p := models.BookingPassenger{
+			GUID:           guid,
+			PassengerNo:    i + 1,
+			Name:           passenger.Name,
+			DocumentNumber: passenger.DocumentNumber,
+			Contact:        passenger.Contact,
+		}
+		if passenger.DateOfBirth != "" {
+			if dob, err := time.Parse(dateLayout, passenger.DateOfBirth); err == nil {
+				p.DateOfBirth = &dob
+			}
+		}
+		record.Passengers = append(record.Passengers, p)
+	}
+
+	if err := tx.Create(&record.Header).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Create(&record.Segments).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Create(&record.Passengers).Error; err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// chargeBooking списывает заранее авторизованную оплату paymentID (см. capturePayment)
+// и переводит бронирование в paid. Возвращает true, как только оплата списана: вызывающий
+// должен вернуть деньги, если транзакция не зафиксируется.
+func chargeBooking(tx *gorm.DB, record *bookingRecord, paymentID string, authorized float64, now time.Time) (bool, error) {
+	captured, err := capturePayment(tx, record.Header.GUID, paymentID, authorized, record.Header.TotalAmount, now)
+	if err != nil {
+		return captured, err
+	}
+
+	record.Header.PaymentID = paymentID
+	record.Header.Status = models.BookStatusPaid
+	return true, tx.Model(&models.BookingHeader{}).
+		Where("guid = ?", record.Header.GUID).
+		Updates(map[string]interface{}{"payment_id": paymentID, "status": models.BookStatusPaid}).Error
+}
+
+// bookingAmount считает стоимость запроса по текущим тарифам без блокировок, чтобы
+// авторизовать оплату до транзакции. false — тарифа нет хотя бы для одного рейса;
+// тогда бронирование всё равно отклонит lockBookableFlights.
+func bookingAmount(req models.BookingRequest) (float64, bool, error) {
+	prices, err := flightPrices(db, req.FlightIDs, req.FareConditions)
+	if err != nil {
+		return 0, false, err
+	}
+	var amount float64
+	for _, flightID := range req.FlightIDs {
+		price, ok := prices[flightID]
+		if !ok {
+			return 0, false, nil
+		}
+		amount += price * float64(len(req.Passengers))
+	}
+	return roundMoney(amount), true, nil
+}
+
+// issueTickets выписывает по билету на каждого пассажира на каждый рейс оплаченного
+// бронирования и переводит его в ticketed.
+func issueTickets(tx *gorm.DB, record *bookingRecord, flightIDs []uint, fareConditions string, prices map[uint]float64) ([]models.TicketFlight, error) {
+	if record.Header.Status != models.BookStatusPaid {
+		return nil, fmt.Errorf("booking %s is %s, tickets are issued only after payment", record.Header.GUID, record.Header.Status)
+	}
+
+	var tickets []models.TicketFlight
+	for _, passenger := range record.Passengers {
+		for _, flightID := range flightIDs {
+			ticketNo, err := ticketNumbers.Next(tx)
+			if err != nil {
+				return nil, err
+			}
+			book := models.Book{
+				TicketNo:    ticketNo,
+				GUID:        record.Header.GUID,
+				FlightID:    flightID,
+				PassengerNo: passenger.PassengerNo,
+			}
+			ticketFlight := models.TicketFlight{
+				TicketNo:       ticketNo,
+				FlightID:       flightID,
+				FareConditions: fareConditions,
+				Amount:         prices[flightID],
+			}
+
+			if err := tx.Create(&book).Error; err != nil {
+				return nil, err
+			}
+			if err := tx.Create(&ticketFlight).Error; err != nil {
+				return nil, err
+			}
+			record.Books = append(record.Books, book)
+			tickets = append(tickets, ticketFlight)
+		}
+	}
+
+	record.Header.Status = models.BookStatusTicketed
+	if err := tx.Model(&models.BookingHeader{}).
+		Where("guid = ?", record.Header.GUID).
+		Update("status", models.BookStatusTicketed).Error; err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+// issuedTickets возвращает билеты бронирования, включая билеты отменённых сегментов.
+func issuedTickets(tx *gorm.DB, record *bookingRecord) ([]models.TicketFlight, error) {
+	ticketNos := make([]string, 0, len(record.Books))
+	for _, book := range record.Books {
+		ticketNos = append(ticketNos, book.TicketNo)
+	}
+	var tickets []models.TicketFlight
+	if len(ticketNos) == 0 {
+		return tickets, nil
+	}
+	if err := tx.Where("ticket_no IN ?", ticketNos).Find(&tickets).Error; err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+// groupTickets раскладывает билеты бронирования по пассажирам.
+func groupTickets(record *bookingRecord, tickets []models.TicketFlight) []models.PassengerTickets {
+	passengers := record.passengers()
+	groups := make([]models.PassengerTickets, len(record.Passengers))
+	index := make(map[int]int, len(record.Passengers))
+	for i, p := range record.Passengers {
+		groups[i] = models.PassengerTickets{PassengerNo: p.PassengerNo, Passenger: passengers[i], Tickets: []models.TicketFlight{}}
+		index[p.PassengerNo] = i
+	}
+
+	passengerByTicket := make(map[string]int, len(record.Books))
+	for _, book := range record.Books {
+		passengerByTicket[book.TicketNo] = book.PassengerNo
+	}
+	for _, ticket := range tickets {
+		if i, ok := index[passengerByTicket[ticket.TicketNo]]; ok {
+			groups[i].Tickets = append(groups[i].Tickets, ticket)
+		}
+	}
+	return groups
+}
+
+// loadBooking дополняет бронирование рейсами, билетами и посадочными талонами.
+// Сегменты упорядочены по времени вылета.
+func loadBooking(record *bookingRecord) (models.Booking, error) {
+	booking := models.Booking{
+		BookingHeader: record.Header,
+		Passengers:    record.passengers(),
+		Segments:      make([]models.BookingSegmentDetails, 0, len(record.Segments)),
+	}
+
+	flightsByID, err := loadFlights(record.flightIDs())
+	if err != nil {
+		return booking, err
+	}
+	zones, err := airportZones()
+	if err != nil {
+		return booking, err
+	}
+	tickets, err := issuedTickets(db, record)
+	if err != nil {
+		return booking, err
+	}
+	var passes []models.BoardingPass
+	if len(record.Books) > 0 {
+		ticketNos := make([]string, 0, len(record.Books))
+		for _, book := range record.Books {
+			ticketNos = append(ticketNos, book.TicketNo)
+		}
+		if err := db.Where("ticket_no IN ?", ticketNos).Find(&passes).Error; err != nil {
+			return booking, err
+		}
+	}
+
+	for _, seg := range record.Segments {
+		details := models.BookingSegmentDetails{
+			BookingSegment: seg,
+			Flight:         flightsByID[seg.FlightID],
+			Tickets:        []models.SegmentTicket{},
+		}
+		details.Flight.ScheduledDeparture = localTime(details.Flight.ScheduledDeparture, details.Flight.DepartureAirport, zones)
+		details.Flight.ScheduledArrival = localTime(details.Flight.ScheduledArrival, details.Flight.ArrivalAirport, zones)
+
+		for _, book := range record.Books {
+			if book.FlightID != seg.FlightID {
+				continue
+			}
+			ticket := models.SegmentTicket{PassengerNo: book.PassengerNo, TicketNo: book.TicketNo}
+			for i := range tickets {
+				if tickets[i].TicketNo == book.TicketNo && tickets[i].FlightID == book.FlightID {
+					ticket.Ticket = &tickets[i]
+				}
+			}
+			for i := range passes {
+				if passes[i].TicketNo == book.TicketNo && passes[i].FlightID == book.FlightID {
+					ticket.BoardingPass = &passes[i]
+				}
+			}
+			details.Tickets = append(details.Tickets, ticket)
+		}
+		booking.Segments = append(booking.Segments, details)
+	}
+
+	sort.SliceStable(booking.Segments, func(i, j int) bool {
+		return booking.Segments[i].Flight.ScheduledDeparture.Before(booking.Segments[j].Flight.ScheduledDeparture)
+	})
+
+	if err := db.Where("guid = ?", record.Header.GUID).Order("change_id").Find(&booking.Changes).Error; err != nil {
+		return booking, err
+	}
+	return booking, nil
+}
+
+const (
+	// Полный возврат при отмене заранее, иначе удерживается часть тарифа.
+	fullRefundBefore = 24 * time.Hour
+	lateRefundRate   = 0.5
+)
+
+// httpError — ожидаемая ошибка бизнес-правила, которую обработчик отдаёт клиенту как ErrorResponse.
+type httpError struct {
+	Status   int
+	Code     string
+	Message  string
+	FlightID *uint
+	Details  []string
+}
+
+func (e *httpError) Error() string {
+	return e.Message
+}
+
+func writeError(w http.ResponseWriter, e *httpError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(models.ErrorResponse{
+		Error:    e.Code,
+		Message:  e.Message,
+		FlightID: e.FlightID,
+		Details:  e.Details,
+	})
+}
+
+// writeFailure отдаёт ошибку бизнес-правила как есть, а остальные — как 500 с message,
+// не раскрывая клиенту подробностей.
+func writeFailure(w http.ResponseWriter, err error, message string) {
+	var he *httpError
+	if errors.As(err, &he) {
+		writeError(w, he)
+		return
+	}
+	writeError(w, internalError(message))
+}
+
+func invalidInput(message string) *httpError {
+	return &httpError{Status: http.StatusBadRequest, Code: "invalid_input", Message: message}
+}
+
+func internalError(message string) *httpError {
+	return &httpError{Status: http.StatusInternalServerError, Code: "internal_error", Message: message}
+}
+
+// normalizeBookingRequest переводит запрос старого формата с одним passanger в список
+// passengers и проверяет данные пассажиров.
+func normalizeBookingRequest(req *models.BookingRequest) error {
+	if len(req.Passengers) == 0 && req.Passanger != "" {
+		req.Passengers = []models.Passenger{{Name: req.Passanger}}
+	}
+	req.Passanger = ""
+
+	if len(req.Passengers) == 0 || len(req.Passengers) > maxPassengers {
+		return fmt.Errorf("booking must have between 1 and %d passengers", maxPassengers)
+	}
+	documents := make(map[string]bool, len(req.Passengers))
+	for i, passenger := range req.Passengers {
+		if passenger.Name == "" {
+			return fmt.Errorf("passenger %d: name is required", i+1)
+		}
+		if passenger.DateOfBirth != "" {
+			if _, err := time.Parse(dateLayout, passenger.DateOfBirth); err != nil {
+				return fmt.Errorf("passenger %d: invalid date_of_birth format. Use YYYY-MM-DD", i+1)
+			}
+		}
+		if passenger.DocumentNumber != "" {
+			if documents[passenger.DocumentNumber] {
+				return fmt.Errorf("passenger %d: document_number is repeated", i+1)
+			}
+			documents[passenger.DocumentNumber] = true
+		}
+	}
+	return nil
+}
+
+// requestFingerprint не зависит от порядка flight_ids: один и тот же набор рейсов — тот же запрос.
+func requestFingerprint(req models.BookingRequest) string {
+	canonical := req
+	canonical.FlightIDs = append([]uint(nil), req.FlightIDs...)
+	sort.Slice(canonical.FlightIDs, func(i, j int) bool { return canonical.FlightIDs[i] < canonical.FlightIDs[j] })
+
+	raw, _ := json.Marshal(canonical)
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func passengerNames(passengers []models.Passenger) string {
+	names := make([]string, 0, len(passengers))
+	for _, passenger := range passengers {
+		names = append(names, passenger.Name)
+	}
+	return fmt.Sprintf("%q", names)
+}
+
+// replayConflict сравнивает повторный запрос с уже сохранённым бронированием
+// и возвращает описание расхождений или nil, если запрос тот же.
+func replayConflict(req models.BookingRequest, fingerprint string, record *bookingRecord) *httpError {
+	// Совпадение отпечатка — тот же запрос, даже если бронирование потом меняли.
+	if record.Header.RequestFingerprint != "" && record.Header.RequestFingerprint == fingerprint {
+		return nil
+	}
+
+	var details []string
+	if booked := record.passengers(); !slices.Equal(booked, req.Passengers) {
+		details = append(details, fmt.Sprintf("passengers: booked %s, requested %s", passengerNames(booked), passengerNames(req.Passengers)))
+	}
+	if len(record.Segments) > 0 && record.Segments[0].FareConditions != req.FareConditions {
+		details = append(details, fmt.Sprintf("fare_conditions: booked %q, requested %q", record.Segments[0].FareConditions, req.FareConditions))
+	}
+
+	booked := record.flightIDs()
+	requested := append([]uint(nil), req.FlightIDs...)
+	slices.Sort(booked)
+	slices.Sort(requested)
+	requested = slices.Compact(requested)
+	if !slices.Equal(booked, requested) {
+		details = append(details, fmt.Sprintf("flight_ids: booked %v, requested %v", booked, requested))
+	}
+
+	// Старые бронирования сохранены без отпечатка — для них достаточно сравнения полей.
+	if len(details) == 0 && record.Header.RequestFingerprint == "" {
+		return nil
+	}
+	if len(details) == 0 {
+		details = append(details, "request body differs from the original booking request")
+	}
+	return &httpError{
+		Status:  http.StatusConflict,
+		Code:    "idempotency_conflict",
+		Message: fmt.Sprintf("booking %s already exists with a different request", record.Header.GUID),
+		Details: details,
+	}
+}
+
+func refundAmount(paid float64, departure, now time.Time) float64 {
+	if departure.Sub(now) >= fullRefundBefore {
+		return paid
+	}
+	return math.Round(paid*lateRefundRate*100) / 100
+}
+
+// cancelSegments освобождает места отменяемых сегментов всех пассажиров и помечает сегменты
+// отменёнными (билеты с оплаченной суммой сохраняются); когда действующих сегментов не остаётся, отменяется и всё бронирование.
+// Сегмент с посадочным талоном отменяется только при force, талон при этом аннулируется.
+// Возвраты записываются как отложенные; провести их нужно settleRefunds после фиксации.
+func cancelSegments(tx *gorm.DB, record *bookingRecord, segments []models.BookingSegment, force bool, now time.Time) (models.CancellationResult, []plannedRefund, error) {
+	result := models.CancellationResult{
+		GUID:     record.Header.GUID,
+		Segments: make([]models.CancelledSegment, 0, len(segments)),
+	}
+
+	flightIDs := make([]uint, 0, len(segments))
+	for _, segment := range segments {
+		flightIDs = append(flightIDs, segment.FlightID)
+	}
+	flightsByID, err := loadFlights(flightIDs)
+	if err != nil {
+		return result, nil, err
+	}
+
+	for _, segment := range segments {
+		flight := flightsByID[segment.FlightID]
+		if !flight.ScheduledDeparture.After(now) {
+			return result, nil, flightError(http.StatusConflict, "flight_departed", segment.FlightID,
+				fmt.Sprintf("flight %d has already departed and cannot be cancelled", segment.FlightID))
+		}
+
+		var segmentRefund float64
+		for _, book := range record.Books {
+			if book.FlightID != segment.FlightID {
+				continue
+			}
+
+			var passes int64
+			if err := tx.Model(&models.BoardingPass{}).
+				Where("ticket_no = ? AND flight_id = ?", book.TicketNo, book.FlightID).
+				Count(&passes).Error; err != nil {
+				return result, nil, err
+			}
+			if passes > 0 {
+				if !force {
+					return result, nil, flightError(http.StatusConflict, "boarding_pass_issued", book.FlightID,
+						fmt.Sprintf("boarding pass already issued for flight %d; repeat with force=true to void it", book.FlightID))
+				}
+				if err := tx.Where("ticket_no = ? AND flight_id = ?", book.TicketNo, book.FlightID).
+					Delete(&models.BoardingPass{}).Error; err != nil {
+					return result, nil, err
+				}
+			}
+
+			// Билет остаётся с оплаченной суммой; место освобождается статусом сегмента (см. seatAvailability).
+			var ticket models.TicketFlight
+			if err := tx.Where("ticket_no = ? AND flight_id = ?", book.TicketNo, book.FlightID).
+				Find(&ticket).Error; err != nil {
+				return result, nil, err
+			}
+
+			refund := refundAmount(ticket.Amount, flight.ScheduledDeparture, now)
+			segmentRefund += refund
+			result.Segments = append(result.Segments, models.CancelledSegment{
+				FlightID:     book.FlightID,
+				TicketNo:     book.TicketNo,
+				AmountPaid:   ticket.Amount,
+				RefundAmount: refund,
+			})
+			result.TotalRefund += refund
+		}
+
+		updated := tx.Model(&models.BookingSegment{}).
+			Where("guid = ? AND flight_id = ? AND status = ?", segment.GUID, segment.FlightID, models.BookStatusActive).
+			Updates(map[string]interface{}{
+				"status":        models.BookStatusCancelled,
+				"cancelled_at":  now,
+				"refund_amount": segmentRefund,
+			})
+		if updated.Error != nil {
+			return result, nil, updated.Error
+		}
+		if updated.RowsAffected != 1 {
+			return result, nil, flightError(http.StatusConflict, "segment_not_active", segment.FlightID,
+				fmt.Sprintf("flight %d of booking %s is no longer active", segment.FlightID, segment.GUID))
+		}
+	}
+
+	var active int64
+	if err := tx.Model(&models.BookingSegment{}).
+		Where("guid = ? AND status = ?", record.Header.GUID, models.BookStatusActive).
+		Count(&active).Error; err != nil {
+		return result, nil, err
+	}
+	if active == 0 {
+		if err := tx.Model(&models.BookingHeader{}).
+			Where("guid = ?", record.Header.GUID).
+			Update("status", models.BookStatusCancelled).Error; err != nil {
+			return result, nil, err
+		}
+	}
+
+	// Возврат не больше списанного записывается в refund_pending; через провайдера его проводит
+	// вызывающий после фиксации (settleRefunds). Бронирования без платежей (созданные до оплаты
+	// через провайдера) возвращаются вне сервиса.
+	result.TotalRefund = roundMoney(result.TotalRefund)
+	var refunds []plannedRefund
+	if result.TotalRefund > 0 {
+		var paid []models.BookingPayment
+		if paid, err = bookingPayments(tx, record.Header.GUID); err != nil {
+			return result, nil, err
+		}
+		if len(paid) > 0 {
+			if refunds, err = planRefunds(tx, paid, result.TotalRefund); err != nil {
+				return result, nil, err
+			}
+			result.TotalRefund = plannedTotal(refunds)
+		}
+	}
+	return result, refunds, nil
+}
+
+// bookableStatuses — статусы рейса до вылета: на такие рейсы можно бронировать и регистрироваться.
+var bookableStatuses = map[string]bool{
+	models.FlightStatusScheduled: true,
+	models.FlightStatusOnTime:    true,
+	models.FlightStatusDelayed:   true,
+}
+
+// lockBookableFlights блокирует строки рейсов (FOR UPDATE, в порядке flight_id, чтобы
+// параллельные бронирования не взаимоблокировались) и проверяет, что на каждом рейсе можно
+// продать seats мест класса fareConditions; места удержания holdGUID считаются свободными.
+// Возвращает тарифы рейсов.
+func lockBookableFlights(tx *gorm.DB, flightIDs []uint, fareConditions string, seats int, holdGUID string, now time.Time) (map[uint]float64, error) {
+	ids := append([]uint(nil), flightIDs...)
+	slices.Sort(ids)
+	if len(slices.Compact(ids)) != len(flightIDs) {
+		return nil, &httpError{Status: http.StatusBadRequest, Code: "duplicate_flight", Message: "flight_ids must not repeat"}
+	}
+
+	flightsByID, err := lockFlights(tx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := flightPrices(tx, ids, fareConditions)
+	if err != nil {
+		return nil, err
+	}
+	available, err := seatAvailability(tx, ids, fareConditions, holdGUID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range flightIDs {
+		flight, ok := flightsByID[id]
+		switch {
+		case !ok:
+			return nil, flightError(http.StatusNotFound, "flight_not_found", id, fmt.Sprintf("flight %d does not exist", id))
+		case flight.Status == models.FlightStatusCancelled:
+			return nil, flightError(http.StatusConflict, "flight_cancelled", id, fmt.Sprintf("flight %d is cancelled", id))
+		case !bookableStatuses[flight.Status] || !flight.ScheduledDeparture.After(now):
+			return nil, flightError(http.StatusConflict, "flight_departed", id, fmt.Sprintf("flight %d has already departed", id))
+		}
+		if _, ok := prices[id]; !ok {
+			return nil, flightError(http.StatusConflict, "fare_not_available", id, fmt.Sprintf("flight %d has no %s fare", id, fareConditions))
+		}
+		if available[id] < seats {
+			return nil, flightError(http.StatusConflict, "sold_out", id, fmt.Sprintf("flight %d has no %s seats left", id, fareConditions))
+		}
+	}
+	return prices, nil
+}
+
+// lockFlights блокирует строки рейсов в порядке flight_id. Все блокировки рейсов в одной
+// транзакции должны браться отсюда одним вызовом, иначе порядок нарушится.
+func lockFlights(tx *gorm.DB, flightIDs []uint) (map[uint]models.Flight, error) {
+	var flights []models.Flight
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("flight_id IN ?", flightIDs).Order("flight_id").
+		Find(&flights).Error; err != nil {
+		return nil, err
+	}
+	flightsByID := make(map[uint]models.Flight, len(flights))
+	for _, flight := range flights {
+		flightsByID[flight.FlightID] = flight
+	}
+	return flightsByID, nil
+}
+
+func flightError(status int, code string, flightID uint, message string) *httpError {
+	return &httpError{
+		Status:   status,
+		Code:     code,
+		Message:  message,
+		FlightID: &flightID,
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/AntonTsoy/airflight-service/internal/models"
+)
+
+func testBookingRequest() models.BookingRequest {
+	return models.BookingRequest{
+		Passengers: []models.Passenger{
+			{Name: "Valeriy Tikhonov", DocumentNumber: "4510 123456", DateOfBirth: "1985-03-14"},
+			{Name: "Anna Tikhonova"},
+		},
+		FareConditions: "Economy",
+		FlightIDs:      []uint{30625, 12000},
+	}
+}
+
+// testBookingRecord — сохранённое бронирование, созданное запросом req.
+func testBookingRecord(req models.BookingRequest, fingerprint string) *bookingRecord {
+	record := &bookingRecord{Header: models.BookingHeader{GUID: "guid-1", RequestFingerprint: fingerprint}}
+	for i, p := range req.Passengers {
+		passenger := models.BookingPassenger{
+			GUID:           "guid-1",
+			PassengerNo:    i + 1,
+			Name:           p.Name,
+			DocumentNumber: p.DocumentNumber,
+			Contact:        p.Contact,
+		}
+		if p.DateOfBirth != "" {
+			birth, _ := time.Parse(dateLayout, p.DateOfBirth)
+			passenger.DateOfBirth = &birth
+		}
+		record.Passengers = append(record.Passengers, passenger)
+	}
+	ids := append([]uint(nil), req.FlightIDs...)
+	slices.Sort(ids)
+	for _, id := range ids {
+		record.Segments = append(record.Segments, models.BookingSegment{
+			GUID:           "guid-1",
+			FlightID:       id,
+			FareConditions: req.FareConditions,
+			Status:         models.BookStatusActive,
+		})
+	}
+	return record
+}
+
+func TestRequestFingerprint(t *testing.T) {
+	req := testBookingRequest()
+	fingerprint := requestFingerprint(req)
+
+	reordered := testBookingRequest()
+	reordered.FlightIDs = []uint{12000, 30625}
+	if got := requestFingerprint(reordered); got != fingerprint {
+		t.Errorf("fingerprint depends on flight_ids order: %s != %s", got, fingerprint)
+	}
+	if !slices.Equal(reordered.FlightIDs, []uint{12000, 30625}) {
+		t.Errorf("requestFingerprint reordered the request flight_ids: %v", reordered.FlightIDs)
+	}
+
+	tests := []struct {
+		name string
+		edit func(req *models.BookingRequest)
+	}{
+		{"fare conditions", func(req *models.BookingRequest) { req.FareConditions = "Business" }},
+		{"flight", func(req *models.BookingRequest) { req.FlightIDs = []uint{30625, 12001} }},
+		{"passenger name", func(req *models.BookingRequest) { req.Passengers[1].Name = "Anna Petrova" }},
+		{"passenger order", func(req *models.BookingRequest) {
+			req.Passengers[0], req.Passengers[1] = req.Passengers[1], req.Passengers[0]
+		}},
+		{"contact", func(req *models.BookingRequest) { req.Passengers[0].Contact = "+7 900 000-00-00" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := testBookingRequest()
+			tt.edit(&changed)
+			if requestFingerprint(changed) == fingerprint {
+				t.Errorf("changing the %s keeps the fingerprint", tt.name)
+			}
+		})
+	}
+}
+
+func TestReplayConflict(t *testing.T) {
+	original := testBookingRequest()
+	fingerprint := requestFingerprint(original)
+
+	tests := []struct {
+		name string
+		// legacy — бронирование сохранено до появления отпечатков.
+		legacy  bool
+		edit    func(req *models.BookingRequest)
+		details []string
+	}{
+		{name: "same request"},
+		{
+			name: "flight ids in another order",
+			edit: func(req *models.BookingRequest) { req.FlightIDs = []uint{12000, 30625} },
+		},
+		{
+			name:    "different fare conditions",
+			edit:    func(req *models.BookingRequest) { req.FareConditions = "Comfort" },
+			details: []string{`fare_conditions: booked "Economy", requested "Comfort"`},
+		},
+		{
+			name:    "different flights",
+			edit:    func(req *models.BookingRequest) { req.FlightIDs = []uint{30625} },
+			details: []string{"flight_ids: booked [12000 30625], requested [30625]"},
+		},
+		{
+			name: "different passengers and flights",
+			edit: func(req *models.BookingRequest) {
+				req.Passengers = req.Passengers[:1]
+				req.FlightIDs = []uint{30625, 12000, 5}
+			},
+			details: []string{
+				`passengers: booked ["Valeriy Tikhonov" "Anna Tikhonova"], requested ["Valeriy Tikhonov"]`,
+				"flight_ids: booked [12000 30625], requested [5 12000 30625]",
+			},
+		},
+		{
+			name:    "same flights but repeated",
+			edit:    func(req *models.BookingRequest) { req.FlightIDs = []uint{30625, 12000, 12000} },
+			details: []string{"request body differs from the original booking request"},
+		},
+		{name: "legacy booking, same fields", legacy: true},
+		{
+			name:    "legacy booking, different fare conditions",
+			legacy:  true,
+			edit:    func(req *models.BookingRequest) { req.FareConditions = "Business" },
+			details: []string{`fare_conditions: booked "Economy", requested "Business"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := fingerprint
+			if tt.legacy {
+				stored = ""
+			}
+			record := testBookingRecord(original, stored)
+
+			req := testBookingRequest()
+			if tt.edit != nil {
+				tt.edit(&req)
+			}
+			conflict := replayConflict(req, requestFingerprint(req), record)
+			if tt.details == nil {
+				if conflict != nil {
+					t.Fatalf("replayConflict() = %v %v, want nil", conflict, conflict.Details)
+				}
+				return
+			}
+			if conflict == nil {
+				t.Fatal("replayConflict() = nil, want a conflict")
+			}
+			if conflict.Status != http.StatusConflict || conflict.Code != "idempotency_conflict" {
+				t.Errorf("replayConflict() = %d %s, want 409 idempotency_conflict", conflict.Status, conflict.Code)
+			}
+			if !slices.Equal(conflict.Details, tt.details) {
+				t.Errorf("details = %q, want %q", conflict.Details, tt.details)
+			}
+		})
+	}
+}
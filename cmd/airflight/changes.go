@@ -0,0 +1,392 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/AntonTsoy/airflight-service/internal/models"
+)
+
+// validateChangeRequest дополняет изменения текущими рейсом и классом сегмента
+// и отклоняет пустые и повторяющиеся изменения.
+func validateChangeRequest(req *models.BookingChangeRequest, record *bookingRecord) error {
+	if len(req.Changes) == 0 {
+		return &httpError{Status: http.StatusBadRequest, Code: "invalid_change", Message: "at least one change is required"}
+	}
+
+	seen := make(map[uint]bool, len(req.Changes))
+	targets := make(map[uint]bool, len(req.Changes))
+	for i := range req.Changes {
+		change := &req.Changes[i]
+		segment := record.segment(change.FlightID)
+		if segment == nil || segment.Status != models.BookStatusActive {
+			return flightError(http.StatusNotFound, "segment_not_found", change.FlightID,
+				fmt.Sprintf("booking %s has no active segment for flight %d", record.Header.GUID, change.FlightID))
+		}
+		if seen[change.FlightID] {
+			return flightError(http.StatusBadRequest, "duplicate_flight", change.FlightID,
+				fmt.Sprintf("flight %d is changed more than once", change.FlightID))
+		}
+		seen[change.FlightID] = true
+
+		if change.NewFlightID == 0 {
+			change.NewFlightID = change.FlightID
+		}
+		if change.FareConditions == "" {
+			change.FareConditions = segment.FareConditions
+		}
+		if !validFareConditions[change.FareConditions] {
+			return &httpError{
+				Status:  http.StatusBadRequest,
+				Code:    "invalid_change",
+				Message: "Invalid fare condition. Must be 'Economy', 'Comfort', 'Business', or 'EconomySec'",
+			}
+		}
+		if change.NewFlightID == change.FlightID && change.FareConditions == segment.FareConditions {
+			return flightError(http.StatusBadRequest, "invalid_change", change.FlightID,
+				fmt.Sprintf("change for flight %d does not change the flight or the fare conditions", change.FlightID))
+		}
+
+		if change.NewFlightID != change.FlightID {
+			// Ключ сегмента — (guid, flight_id), поэтому рейс, уже бывший в бронировании, снова не добавить.
+			if record.segment(change.NewFlightID) != nil || targets[change.NewFlightID] {
+				return flightError(http.StatusConflict, "flight_already_booked", change.NewFlightID,
+					fmt.Sprintf("flight %d is already part of booking %s", change.NewFlightID, record.Header.GUID))
+			}
+			targets[change.NewFlightID] = true
+		}
+	}
+	return nil
+}
+
+// changeSegment переносит билеты всех пассажиров сегмента на новый рейс и/или класс.
+// Номера билетов сохраняются, посадочные талоны аннулируются, тариф пересчитывается.
+// Рейс назначения должен быть уже заблокирован и проверен lockBookableFlights.
+func changeSegment(tx *gorm.DB, record *bookingRecord, change models.SegmentChange, price float64, now time.Time) (models.BookingChange, error) {
+	segment := record.segment(change.FlightID)
+	entry := models.BookingChange{
+		GUID:              record.Header.GUID,
+		OldFlightID:       change.FlightID,
+		NewFlightID:       change.NewFlightID,
+		OldFareConditions: segment.FareConditions,
+		NewFareConditions: change.FareConditions,
+		ChangedAt:         now,
+	}
+
+	flightsByID, err := loadFlights([]uint{change.FlightID})
+	if err != nil {
+		return entry, err
+	}
+	if !flightsByID[change.FlightID].ScheduledDeparture.After(now) {
+		return entry, flightError(http.StatusConflict, "flight_departed", change.FlightID,
+			fmt.Sprintf("flight %d has already departed and cannot be changed", change.FlightID))
+	}
+
+	if change.NewFlightID != change.FlightID {
+		// Новый сегмент создаётся до переноса билетов: на него ссылается внешний ключ books.
+		if err := tx.Create(&models.BookingSegment{
+			GUID:           record.Header.GUID,
+			FlightID:       change.NewFlightID,
+			FareConditions: change.FareConditions,
+			Status:         models.BookStatusActive,
+		}).Error; err != nil {
+			return entry, err
+		}
+	}
+
+	for _, book := range record.Books {
+		if book.FlightID != change.FlightID {
+			continue
+		}
+
+		deleted := tx.Where("ticket_no = ? AND flight_id = ?", book.TicketNo, book.FlightID).
+			Delete(&models.BoardingPass{})
+		if deleted.Error != nil {
+			return entry, deleted.Error
+		}
+		entry.VoidedPasses += int(deleted.RowsAffected)
+
+		var ticket models.TicketFlight
+		if err := tx.Where("ticket_no = ? AND flight_id = ?", book.TicketNo, book.FlightID).
+			Find(&ticket).Error; err != nil {
+			return entry, err
+		}
+		if err := tx.Where("ticket_no = ? AND flight_id = ?", book.TicketNo, book.FlightID).
+			Delete(&models.TicketFlight{}).Error; err != nil {
+			return entry, err
+		}
+		if change.NewFlightID != change.FlightID {
+			if err := tx.Model(&models.Book{}).Where("ticket_no = ?", book.TicketNo).
+				Update("flight_id", change.NewFlightID).Error; err != nil {
+				return entry, err
+			}
+		}
+		if err := tx.Create(&models.TicketFlight{
+			TicketNo:       book.TicketNo,
+			FlightID:       change.NewFlightID,
+			FareConditions: change.FareConditions,
+			Amount:         price,
+		}).Error; err != nil {
+			return entry, err
+		}
+
+		entry.Passengers++
+		entry.OldAmount += ticket.Amount
+		entry.NewAmount += price
+	}
+	entry.FareDifference = math.Round((entry.NewAmount-entry.OldAmount)*100) / 100
+
+	if change.NewFlightID != change.FlightID {
+		if err := tx.Model(&models.BookingSegment{}).
+			Where("guid = ? AND flight_id = ?", record.Header.GUID, change.FlightID).
+			Update("status", models.BookStatusChanged).Error; err != nil {
+			return entry, err
+		}
+	} else {
+		if err := tx.Model(&models.BookingSegment{}).
+			Where("guid = ? AND flight_id = ?", record.Header.GUID, change.FlightID).
+			Update("fare_conditions", change.FareConditions).Error; err != nil {
+			return entry, err
+		}
+	}
+
+	if err := tx.Create(&entry).Error; err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// checkSameRoute требует, чтобы новый рейс связывал те же города, что и заменяемый:
+// иначе маршрут бронирования перестаёт быть непрерывным.
+func checkSameRoute(record *bookingRecord, changes []models.SegmentChange, targets map[uint]models.Flight) error {
+	var oldIDs []uint
+	for _, change := range changes {
+		if change.NewFlightID != change.FlightID {
+			oldIDs = append(oldIDs, change.FlightID)
+		}
+	}
+	if len(oldIDs) == 0 {
+		return nil
+	}
+	current, err := loadFlights(oldIDs)
+	if err != nil {
+		return err
+	}
+
+	var codes []string
+	for _, change := range changes {
+		if target, ok := targets[change.NewFlightID]; ok {
+			codes = append(codes, target.DepartureAirport, target.ArrivalAirport)
+		}
+		old := current[change.FlightID]
+		codes = append(codes, old.DepartureAirport, old.ArrivalAirport)
+	}
+	var airports []models.Airport
+	if err := db.Where("airport_code IN ?", codes).Find(&airports).Error; err != nil {
+		return err
+	}
+	cities := make(map[string]string, len(airports))
+	for _, airport := range airports {
+		cities[airport.AirportCode] = airport.City
+	}
+
+	for _, change := range changes {
+		if change.NewFlightID == change.FlightID {
+			continue
+		}
+		target, ok := targets[change.NewFlightID]
+		if !ok {
+			return flightError(http.StatusNotFound, "flight_not_found", change.NewFlightID,
+				fmt.Sprintf("flight %d does not exist", change.NewFlightID))
+		}
+		old := current[change.FlightID]
+		if cities[target.DepartureAirport] != cities[old.DepartureAirport] ||
+			cities[target.ArrivalAirport] != cities[old.ArrivalAirport] {
+			return flightError(http.StatusConflict, "route_mismatch", change.NewFlightID,
+				fmt.Sprintf("flight %d (%s-%s) does not replace flight %d (%s-%s) of booking %s",
+					change.NewFlightID, target.DepartureAirport, target.ArrivalAirport,
+					change.FlightID, old.DepartureAirport, old.ArrivalAirport, record.Header.GUID))
+		}
+	}
+	return nil
+}
+
+// checkConnections требует, чтобы после замены рейсов действующие сегменты бронирования
+// по-прежнему шли друг за другом: каждый вылетает не раньше прилёта предыдущего плюс минимальное
+// время пересадки (MIN_CONNECTION_TIME, но не меньше MCT аэропорта), как при поиске маршрутов.
+// Верхняя граница пересадки не проверяется: между сегментами туда-обратно проходят дни.
+func checkConnections(tx *gorm.DB, record *bookingRecord, changes []models.SegmentChange, targets map[uint]models.Flight) error {
+	replaced := make(map[uint]uint, len(changes))
+	for _, change := range changes {
+		if change.NewFlightID != change.FlightID {
+			replaced[change.FlightID] = change.NewFlightID
+		}
+	}
+	if len(replaced) == 0 {
+		return nil
+	}
+
+	var activeIDs []uint
+	for _, segment := range record.Segments {
+		if segment.Status == models.BookStatusActive {
+			activeIDs = append(activeIDs, segment.FlightID)
+		}
+	}
+	current, err := loadFlights(activeIDs)
+	if err != nil {
+		return err
+	}
+	// Порядок сегментов — исходный порядок вылетов; заменённый рейс занимает место прежнего.
+	slices.SortFunc(activeIDs, func(a, b uint) int {
+		return current[a].ScheduledDeparture.Compare(current[b].ScheduledDeparture)
+	})
+	itinerary := make([]models.Flight, len(activeIDs))
+	var airports []string
+	for i, id := range activeIDs {
+		itinerary[i] = current[id]
+		if newID, ok := replaced[id]; ok {
+			itinerary[i] = targets[newID]
+		}
+		airports = append(airports, itinerary[i].ArrivalAirport)
+	}
+
+	var connectionTimes []models.AirportConnectionTime
+	if err := tx.Where("airport_code IN ?", airports).Find(&connectionTimes).Error; err != nil {
+		return err
+	}
+	mct := make(map[string]time.Duration, len(connectionTimes))
+	for _, ct := range connectionTimes {
+		mct[ct.AirportCode] = time.Duration(ct.MinConnectionMinutes) * time.Minute
+	}
+
+	for i := 1; i < len(itinerary); i++ {
+		prev, next := itinerary[i-1], itinerary[i]
+		_, prevChanged := replaced[activeIDs[i-1]]
+		_, nextChanged := replaced[activeIDs[i]]
+		if !prevChanged && !nextChanged {
+			continue
+		}
+		minConnection := max(cfg.MinConnectionTime.Truncate(time.Minute), mct[prev.ArrivalAirport])
+		if next.ScheduledDeparture.Before(prev.ScheduledArrival.Add(minConnection)) {
+			flightID := next.FlightID
+			if !nextChanged {
+				flightID = prev.FlightID
+			}
+			return flightError(http.StatusConflict, "route_mismatch", flightID,
+				fmt.Sprintf("flight %d departs at %s, less than %s after flight %d arrives at %s",
+					next.FlightID, next.ScheduledDeparture.Format(time.RFC3339), minConnection,
+					prev.FlightID, prev.ScheduledArrival.Format(time.RFC3339)))
+		}
+	}
+	return nil
+}
+
+// changeBooking применяет все изменения в одной транзакции: либо все, либо ни одно.
+// Доплата списывается с заранее авторизованного платежа paymentID (см. changeAmount);
+// result.PaymentID заполняется, как только она списана, и тогда вызывающий должен вернуть
+// деньги, если транзакция не зафиксируется. Возврат разницы записывается как отложенный
+// и проводится settleRefunds после фиксации.
+func changeBooking(tx *gorm.DB, record *bookingRecord, req models.BookingChangeRequest, paymentID string, authorized float64, now time.Time) (models.BookingChangeResult, []plannedRefund, error) {
+	result := models.BookingChangeResult{
+		GUID:    record.Header.GUID,
+		Changes: make([]models.BookingChange, 0, len(req.Changes)),
+	}
+
+	// Все рейсы назначения блокируются сразу в порядке flight_id; lockBookableFlights ниже
+	// повторно блокирует уже удерживаемые строки и порядок не нарушает.
+	targets := make([]uint, 0, len(req.Changes))
+	for _, change := range req.Changes {
+		targets = append(targets, change.NewFlightID)
+	}
+	slices.Sort(targets)
+	locked, err := lockFlights(tx, targets)
+	if err != nil {
+		return result, nil, err
+	}
+	if err := checkSameRoute(record, req.Changes, locked); err != nil {
+		return result, nil, err
+	}
+	if err := checkConnections(tx, record, req.Changes, locked); err != nil {
+		return result, nil, err
+	}
+
+	passengers := len(record.Passengers)
+	for _, change := range req.Changes {
+		// Места проверяются в новом классе; на том же рейсе при смене класса
+		// освобождаемые места старого класса не мешают.
+		prices, err := lockBookableFlights(tx, []uint{change.NewFlightID}, change.FareConditions, passengers, "", now)
+		if err != nil {
+			return result, nil, err
+		}
+		entry, err := changeSegment(tx, record, change, prices[change.NewFlightID], now)
+		if err != nil {
+			return result, nil, err
+		}
+		result.Changes = append(result.Changes, entry)
+		result.TotalDifference += entry.FareDifference
+	}
+	result.TotalDifference = math.Round(result.TotalDifference*100) / 100
+
+	result.TotalAmount = math.Round((record.Header.TotalAmount+result.TotalDifference)*100) / 100
+	if err := tx.Model(&models.BookingHeader{}).
+		Where("guid = ?", record.Header.GUID).
+		Update("total_amount", result.TotalAmount).Error; err != nil {
+		return result, nil, err
+	}
+
+	switch {
+	case result.TotalDifference > 0:
+		captured, err := capturePayment(tx, record.Header.GUID, paymentID, authorized, result.TotalDifference, now)
+		if captured {
+			result.PaymentID = paymentID
+		}
+		return result, nil, err
+	case result.TotalDifference < 0:
+		paid, err := bookingPayments(tx, record.Header.GUID)
+		if err != nil {
+			return result, nil, err
+		}
+		refunds, err := planRefunds(tx, paid, -result.TotalDifference)
+		return result, refunds, err
+	}
+	return result, nil, nil
+}
+
+// changeAmount оценивает доплату за изменения по текущим тарифам без блокировок, чтобы
+// авторизовать её до транзакции, как bookingAmount. Изменения должны быть дополнены
+// validateChangeRequest. Если тариф нового рейса не найден, возвращается 0: изменение
+// всё равно отклонит lockBookableFlights.
+func changeAmount(record *bookingRecord, changes []models.SegmentChange) (float64, error) {
+	var total float64
+	for _, change := range changes {
+		prices, err := flightPrices(db, []uint{change.NewFlightID}, change.FareConditions)
+		if err != nil {
+			return 0, err
+		}
+		price, ok := prices[change.NewFlightID]
+		if !ok {
+			return 0, nil
+		}
+
+		var oldAmount, newAmount float64
+		for _, book := range record.Books {
+			if book.FlightID != change.FlightID {
+				continue
+			}
+			var ticket models.TicketFlight
+			if err := db.Where("ticket_no = ? AND flight_id = ?", book.TicketNo, book.FlightID).
+				Find(&ticket).Error; err != nil {
+				return 0, err
+			}
+			oldAmount += ticket.Amount
+			newAmount += price
+		}
+		total += math.Round((newAmount-oldAmount)*100) / 100
+	}
+	return roundMoney(total), nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	"github.com/AntonTsoy/airflight-service/internal/models"
+	"github.com/AntonTsoy/airflight-service/internal/seats"
+)
+
+// Сколько раз повторяется транзакция регистрации, прерванная конкурирующей.
+const checkInAttempts = 5
+
+// retryableError распознаёт ошибки, после которых транзакцию можно просто повторить:
+// сбой сериализации и взаимоблокировку.
+func retryableError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	switch pgErr.Code {
+	case "40001", "40P01":
+		return true
+	}
+	return false
+}
+
+// uniqueViolation распознаёт нарушение уникальности. Под блокировкой строки рейса место
+// и номер посадочного не могут занять параллельно, поэтому повтор тут не поможет:
+// значит, талон записал кто-то в обход регистрации.
+func uniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func retryTransaction(attempts int, fn func(tx *gorm.DB) error) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = db.Transaction(fn); err == nil || !retryableError(err) {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * 10 * time.Millisecond)
+	}
+	return err
+}
+
+func checkInNotFound(guid string, flightID uint) *httpError {
+	return &httpError{
+		Status:   http.StatusNotFound,
+		Code:     "booking_not_found",
+		Message:  fmt.Sprintf("booking not found for GUID %s and flight ID %d", guid, flightID),
+		FlightID: &flightID,
+	}
+}
+
+// checkInAllowed проверяет статус рейса и окно регистрации относительно планового вылета.
+func checkInAllowed(flight models.Flight, now time.Time) error {
+	opens := flight.ScheduledDeparture.Add(-cfg.CheckInOpens)
+	closes := flight.ScheduledDeparture.Add(-cfg.CheckInCloses)
+	switch {
+	case flight.Status == models.FlightStatusCancelled:
+		return flightError(http.StatusConflict, "flight_cancelled", flight.FlightID,
+			fmt.Sprintf("flight %d is cancelled", flight.FlightID))
+	case !bookableStatuses[flight.Status] || !now.Before(closes):
+		return flightError(http.StatusConflict, "checkin_closed", flight.FlightID,
+			fmt.Sprintf("check-in for flight %d closed at %s", flight.FlightID, closes.Format(time.RFC3339)))
+	case now.Before(opens):
+		return flightError(http.StatusConflict, "checkin_too_early", flight.FlightID,
+			fmt.Sprintf("check-in for flight %d opens at %s", flight.FlightID, opens.Format(time.RFC3339)))
+	}
+	return nil
+}
+
+var validSeatPreferences = map[string]bool{"": true, string(seats.Window): true, string(seats.Aisle): true}
+
+// cabinLayout возвращает все места самолёта рейса с типами и классами обслуживания.
+func cabinLayout(tx *gorm.DB, flightID uint) ([]seats.Seat, map[string]string, error) {
+	var cabin []models.Seat
+	if err := tx.Table("seats s").
+		Select("s.aircraft_code, s.seat_no, s.fare_conditions").
+		Joins("JOIN flights f ON f.aircraft_code = s.aircraft_code").
+		Where("f.flight_id = ?", flightID).
+		Find(&cabin).Error; err != nil {
+		return nil, nil, err
+	}
+
+	seatNos := make([]string, 0, len(cabin))
+	fares := make(map[string]string, len(cabin))
+	for _, seat := range cabin {
+		seatNos = append(seatNos, seat.SeatNo)
+		fares[seat.SeatNo] = seat.FareConditions
+	}
+	layout, err := seats.Layout(seatNos)
+	if err != nil {
+		return nil, nil, err
+	}
+	return layout, fares, nil
+}
+
+func occupiedSeats(tx *gorm.DB, flightID uint) (map[string]bool, error) {
+	var seatNos []string
+	if err := tx.Model(&models.BoardingPass{}).Where("flight_id = ?", flightID).Pluck("seat_no", &seatNos).Error; err != nil {
+		return nil, err
+	}
+	occupied := make(map[string]bool, len(seatNos))
+	for _, no := range seatNos {
+		occupied[no] = true
+	}
+	return occupied, nil
+}
+
+// freeSeats отбирает из layout кресла класса fareConditions, которые не заняты и не удержаны.
+func freeSeats(layout []seats.Seat, fares map[string]string, fareConditions string, occupied, held map[string]bool) []seats.Seat {
+	free := make([]seats.Seat, 0, len(layout))
+	for _, seat := range layout {
+		if fares[seat.No] == fareConditions && !occupied[seat.No] && !held[seat.No] {
+			free = append(free, seat)
+		}
+	}
+	return free
+}
+
+// assignSeat проверяет выбранное пассажиром место или подбирает свободное место класса
+// fareConditions по seats.Pick. Кресла активных удержаний не выдаются: они ждут оформления
+// бронирования.
+func assignSeat(tx *gorm.DB, flightID uint, fareConditions string, req models.CheckInRequest, now time.Time) (string, error) {
+	layout, fares, err := cabinLayout(tx, flightID)
+	if err != nil {
+		return "", err
+	}
+	occupied, err := occupiedSeats(tx, flightID)
+	if err != nil {
+		return "", err
+	}
+	held, err := heldSeats(tx, flightID, now)
+	if err != nil {
+		return "", err
+	}
+
+	if req.SeatNo != "" {
+		fare, ok := fares[req.SeatNo]
+		switch {
+		case !ok:
+			return "", flightError(http.StatusBadRequest, "seat_not_found", flightID,
+				fmt.Sprintf("seat %s does not exist on the aircraft of flight %d", req.SeatNo, flightID))
+		case fare != fareConditions:
+			return "", flightError(http.StatusBadRequest, "seat_wrong_class", flightID,
+				fmt.Sprintf("seat %s is %s, but the ticket is %s", req.SeatNo, fare, fareConditions))
+		case occupied[req.SeatNo]:
+			return "", flightError(http.StatusConflict, "seat_taken", flightID,
+				fmt.Sprintf("seat %s on flight %d is already taken", req.SeatNo, flightID))
+		case held[req.SeatNo]:
+			return "", flightError(http.StatusConflict, "seat_held", flightID,
+				fmt.Sprintf("seat %s on flight %d is held for another booking", req.SeatNo, flightID))
+		}
+		return req.SeatNo, nil
+	}
+
+	seat, ok := seats.Pick(freeSeats(layout, fares, fareConditions, occupied, held), seats.Kind(req.SeatPreference))
+	if !ok {
+		return "", flightError(http.StatusNotFound, "no_free_seats", flightID,
+			fmt.Sprintf("no available seats for fare condition %s on flight %d", fareConditions, flightID))
+	}
+	return seat.No, nil
+}
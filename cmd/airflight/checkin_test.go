@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/AntonTsoy/airflight-service/internal/config"
+	"github.com/AntonTsoy/airflight-service/internal/models"
+	"github.com/AntonTsoy/airflight-service/internal/ticketno"
+)
+
+func TestCheckInAllowed(t *testing.T) {
+	cfg = &config.Config{CheckInOpens: 24 * time.Hour, CheckInCloses: 40 * time.Minute}
+	departure := time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)
+	closes := departure.Add(-40 * time.Minute)
+
+	tests := []struct {
+		name   string
+		status string
+		now    time.Time
+		code   string
+	}{
+		{"too early", models.FlightStatusScheduled, departure.Add(-24*time.Hour - time.Second), "checkin_too_early"},
+		{"opens", models.FlightStatusScheduled, departure.Add(-24 * time.Hour), ""},
+		{"just before close", models.FlightStatusOnTime, closes.Add(-time.Second), ""},
+		{"delayed", models.FlightStatusDelayed, departure.Add(-2 * time.Hour), ""},
+		{"exactly at close", models.FlightStatusOnTime, closes, "checkin_closed"},
+		{"after departure", models.FlightStatusOnTime, departure.Add(time.Hour), "checkin_closed"},
+		{"cancelled", models.FlightStatusCancelled, departure.Add(-2 * time.Hour), "flight_cancelled"},
+		{"cancelled too early", models.FlightStatusCancelled, departure.Add(-48 * time.Hour), "flight_cancelled"},
+		{"departed", models.FlightStatusDeparted, departure.Add(-2 * time.Hour), "checkin_closed"},
+		{"arrived", models.FlightStatusArrived, departure.Add(-2 * time.Hour), "checkin_closed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flight := models.Flight{FlightID: 7, ScheduledDeparture: departure, Status: tt.status}
+			err := checkInAllowed(flight, tt.now)
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("checkInAllowed() = %v, want nil", err)
+				}
+				return
+			}
+			var httpErr *httpError
+			if !errors.As(err, &httpErr) {
+				t.Fatalf("checkInAllowed() = %v, want %s", err, tt.code)
+			}
+			if httpErr.Status != http.StatusConflict || httpErr.Code != tt.code {
+				t.Errorf("checkInAllowed() = %d %s, want 409 %s", httpErr.Status, httpErr.Code, tt.code)
+			}
+			if httpErr.FlightID == nil || *httpErr.FlightID != flight.FlightID {
+				t.Errorf("checkInAllowed() flight_id = %v, want %d", httpErr.FlightID, flight.FlightID)
+			}
+		})
+	}
+}
+
+// TestConcurrentCheckIn регистрирует одновременно всех пассажиров одного рейса и проверяет,
+// что места и номера посадочных не повторяются. Нужна база с D4.sql; адрес задаётся
+// TEST_DATABASE_DSN, без него тест пропускается. Тест создаёт свой рейс и удаляет его после.
+func TestConcurrentCheckIn(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	var err error
+	db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg = &config.Config{CheckInOpens: 24 * time.Hour, CheckInCloses: 40 * time.Minute, TicketPrefix: "999"}
+	if ticketNumbers, err = ticketno.NewSequential(cfg.TicketPrefix, uint64(time.Now().UnixNano()%900_000_000)); err != nil {
+		t.Fatal(err)
+	}
+
+	const passengers = 40
+	flightID := createTestFlight(t)
+	guids := createTestBookings(t, flightID, passengers)
+
+	router := chi.NewRouter()
+	router.Put("/bookings/{guid}/check-in/{flight_id}", checkIn)
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		passes []models.BoardingPass
+	)
+	start := make(chan struct{})
+	for _, guid := range guids {
+		wg.Add(1)
+		go func(guid string) {
+			defer wg.Done()
+			<-start
+			req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/bookings/%s/check-in/%d", guid, flightID), nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Errorf("check-in %s: status %d: %s", guid, rec.Code, rec.Body.String())
+				return
+			}
+			var pass models.BoardingPass
+			if err := json.Unmarshal(rec.Body.Bytes(), &pass); err != nil {
+				t.Errorf("check-in %s: %v", guid, err)
+				return
+			}
+			mu.Lock()
+			passes = append(passes, pass)
+			mu.Unlock()
+		}(guid)
+	}
+	close(start)
+	wg.Wait()
+
+	if len(passes) != passengers {
+		t.Fatalf("got %d boarding passes, want %d", len(passes), passengers)
+	}
+	seatsTaken := make(map[string]string, len(passes))
+	numbers := make(map[int]string, len(passes))
+	for _, pass := range passes {
+		if other, ok := seatsTaken[pass.SeatNo]; ok {
+			t.Errorf("seat %s assigned to %s and %s", pass.SeatNo, other, pass.TicketNo)
+		}
+		if other, ok := numbers[pass.BoardingNo]; ok {
+			t.Errorf("boarding number %d assigned to %s and %s", pass.BoardingNo, other, pass.TicketNo)
+		}
+		if pass.BoardingNo < 1 || pass.BoardingNo > passengers {
+			t.Errorf("boarding number %d is outside 1..%d", pass.BoardingNo, passengers)
+		}
+		seatsTaken[pass.SeatNo] = pass.TicketNo
+		numbers[pass.BoardingNo] = pass.TicketNo
+	}
+}
+
+// createTestFlight создаёт рейс, регистрация на который открыта сейчас.
+func createTestFlight(t *testing.T) uint {
+	t.Helper()
+	departure := time.Now().Add(3 * time.Hour).Truncate(time.Second)
+	var flightID uint
+	if err := db.Raw(`
+        INSERT INTO flights (flight_no, scheduled_departure, scheduled_arrival,
+                             departure_airport, arrival_airport, status, aircraft_code)
+        VALUES ('PG9999', ?, ?, 'DME', 'LED', ?, '321')
+        RETURNING flight_id`,
+		departure, departure.Add(90*time.Minute), models.FlightStatusOnTime).Scan(&flightID).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		var guids []string
+		db.Model(&models.BookingSegment{}).Where("flight_id = ?", flightID).Pluck("guid", &guids)
+		db.Where("flight_id = ?", flightID).Delete(&models.BoardingPass{})
+		db.Where("flight_id = ?", flightID).Delete(&models.TicketFlight{})
+		db.Where("flight_id = ?", flightID).Delete(&models.Book{})
+		db.Where("flight_id = ?", flightID).Delete(&models.BookingSegment{})
+		if len(guids) > 0 {
+			db.Where("guid IN ?", guids).Delete(&models.BookingPassenger{})
+			db.Where("guid IN ?", guids).Delete(&models.BookingHeader{})
+		}
+		db.Exec("DELETE FROM flights WHERE flight_id = ?", flightID)
+	})
+	return flightID
+}
+
+// createTestBookings оформляет n бронирований по одному пассажиру эконом-класса напрямую в базе.
+func createTestBookings(t *testing.T, flightID uint, n int) []string {
+	t.Helper()
+	now := time.Now()
+	guids := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		guid := fmt.Sprintf("checkin-test-%d-%d", now.UnixNano(), i)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			record, err := createBooking(tx, guid, models.BookingRequest{
+				Passengers:     []models.Passenger{{Name: fmt.Sprintf("TEST PASSENGER %d", i)}},
+				FareConditions: "Economy",
+				FlightIDs:      []uint{flightID},
+			}, "", map[uint]float64{flightID: 1000}, now)
+			if err != nil {
+				return err
+			}
+			record.Header.Status = models.BookStatusPaid
+			_, err = issueTickets(tx, record, []uint{flightID}, "Economy", map[uint]float64{flightID: 1000})
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		guids = append(guids, guid)
+	}
+	return guids
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"gorm.io/gorm"
+)
+
+// flightPrices возвращает тариф класса fareConditions для каждого рейса по таблице delivery_prices.
+// Рейсы, для которых тариф класса не найден, в результат не попадают.
+func flightPrices(tx *gorm.DB, flightIDs []uint, fareConditions string) (map[uint]float64, error) {
+	prices := make(map[uint]float64, len(flightIDs))
+	if len(flightIDs) == 0 {
+		return prices, nil
+	}
+
+	var rows []struct {
+		FlightID uint
+		Price    float64
+	}
+	if err := tx.Raw(`
+        SELECT f.flight_id, MIN(dp.price) AS price
+        FROM flights f
+        JOIN delivery_prices dp ON dp.aircraft_code = f.aircraft_code
+            AND dp.departure_airport = f.departure_airport
+            AND dp.arrival_airport = f.arrival_airport
+        WHERE f.flight_id IN ? AND dp.fare_conditions = ?
+        GROUP BY f.flight_id`,
+		flightIDs, fareConditions).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		prices[row.FlightID] = row.Price
+	}
+	return prices, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/AntonTsoy/airflight-service/internal/models"
+	"github.com/AntonTsoy/airflight-service/internal/seats"
+)
+
+func findActiveHold(tx *gorm.DB, guid string, now time.Time) ([]models.SeatHold, error) {
+	var holds []models.SeatHold
+	if err := tx.Where("guid = ? AND status = ? AND expires_at > ?", guid, models.HoldStatusActive, now).
+		Order("flight_id").Find(&holds).Error; err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+func newHold(guid string, holds []models.SeatHold) models.Hold {
+	hold := models.Hold{
+		GUID:           guid,
+		FareConditions: holds[0].FareConditions,
+		Seats:          holds[0].Seats,
+		Status:         holds[0].Status,
+		ExpiresAt:      holds[0].ExpiresAt,
+		FlightIDs:      make([]uint, 0, len(holds)),
+	}
+	for _, h := range holds {
+		hold.FlightIDs = append(hold.FlightIDs, h.FlightID)
+	}
+	return hold
+}
+
+// holdMismatch сравнивает удержание с запросом: flight_ids без учёта порядка,
+// а мест должно быть удержано не меньше, чем требуется.
+func holdMismatch(holds []models.SeatHold, fareConditions string, flightIDs []uint, seats int) []string {
+	var details []string
+	if holds[0].FareConditions != fareConditions {
+		details = append(details, fmt.Sprintf("fare_conditions: held %q, requested %q", holds[0].FareConditions, fareConditions))
+	}
+	if holds[0].Seats < seats {
+		details = append(details, fmt.Sprintf("seats: held %d, requested %d", holds[0].Seats, seats))
+	}
+
+	held := make([]uint, 0, len(holds))
+	for _, h := range holds {
+		held = append(held, h.FlightID)
+	}
+	requested := append([]uint(nil), flightIDs...)
+	slices.Sort(requested)
+	if !slices.Equal(held, requested) {
+		details = append(details, fmt.Sprintf("flight_ids: held %v, requested %v", held, requested))
+	}
+	return details
+}
+
+// heldSeats возвращает кресла рейса, закреплённые за активными непросроченными удержаниями.
+func heldSeats(tx *gorm.DB, flightID uint, now time.Time) (map[string]bool, error) {
+	var seatNos []string
+	if err := tx.Table("seat_hold_seats hs").
+		Joins("JOIN seat_holds h ON h.guid = hs.guid AND h.flight_id = hs.flight_id").
+		Where("hs.flight_id = ? AND h.status = ? AND h.expires_at > ?", flightID, models.HoldStatusActive, now).
+		Pluck("hs.seat_no", &seatNos).Error; err != nil {
+		return nil, err
+	}
+	held := make(map[string]bool, len(seatNos))
+	for _, no := range seatNos {
+		held[no] = true
+	}
+	return held, nil
+}
+
+// assignHoldSeats закрепляет за удержанием hold.Seats кресел его класса, выбирая их по
+// seats.Pick среди незанятых и неудержанных. Вызывается под блокировкой строки рейса
+// (lockBookableFlights), как и регистрация, поэтому кресла не пересекаются.
+func assignHoldSeats(tx *gorm.DB, hold models.SeatHold, now time.Time) error {
+	layout, fares, err := cabinLayout(tx, hold.FlightID)
+	if err != nil {
+		return err
+	}
+	occupied, err := occupiedSeats(tx, hold.FlightID)
+	if err != nil {
+		return err
+	}
+	held, err := heldSeats(tx, hold.FlightID, now)
+	if err != nil {
+		return err
+	}
+
+	free := freeSeats(layout, fares, hold.FareConditions, occupied, held)
+	rows := make([]models.SeatHoldSeat, 0, hold.Seats)
+	for len(rows) < hold.Seats {
+		seat, ok := seats.Pick(free, "")
+		if !ok {
+			return flightError(http.StatusConflict, "sold_out", hold.FlightID,
+				fmt.Sprintf("flight %d has no %s seats left", hold.FlightID, hold.FareConditions))
+		}
+		rows = append(rows, models.SeatHoldSeat{GUID: hold.GUID, FlightID: hold.FlightID, SeatNo: seat.No})
+		free = slices.DeleteFunc(free, func(s seats.Seat) bool { return s.No == seat.No })
+	}
+	return tx.Create(&rows).Error
+}
+
+// confirmHold проверяет, что удержание бронирования guid (если оно есть) покрывает запрос,
+// и помечает его подтверждённым. Закреплённые кресла освобождаются: места теперь учтены
+// билетами, а кресло пассажир получит при регистрации.
+func confirmHold(tx *gorm.DB, guid string, req models.BookingRequest, now time.Time) error {
+	holds, err := findActiveHold(tx, guid, now)
+	if err != nil || len(holds) == 0 {
+		return err
+	}
+	if details := holdMismatch(holds, req.FareConditions, req.FlightIDs, len(req.Passengers)); len(details) > 0 {
+		return &httpError{
+			Status:  http.StatusConflict,
+			Code:    "hold_mismatch",
+			Message: fmt.Sprintf("booking request does not match the seat hold for GUID %s", guid),
+			Details: details,
+		}
+	}
+	if err := tx.Model(&models.SeatHold{}).
+		Where("guid = ? AND status = ?", guid, models.HoldStatusActive).
+		Update("status", models.HoldStatusConfirmed).Error; err != nil {
+		return err
+	}
+	return tx.Where("guid = ?", guid).Delete(&models.SeatHoldSeat{}).Error
+}
+
+func expireHolds(now time.Time) (int64, error) {
+	result := db.Model(&models.SeatHold{}).
+		Where("status = ? AND expires_at <= ?", models.HoldStatusActive, now).
+		Update("status", models.HoldStatusExpired)
+	return result.RowsAffected, result.Error
+}
+
+// runHoldSweeper помечает просроченные удержания. Места освобождаются и без него —
+// seatAvailability не учитывает удержания с истёкшим expires_at, — но так таблица
+// отражает фактическое состояние.
+func runHoldSweeper(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			n, err := expireHolds(time.Now())
+			if err != nil {
+				log.Println("failed to expire seat holds:", err)
+			} else if n > 0 {
+				log.Printf("expired %d seat holds", n)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/AntonTsoy/airflight-service/internal/models"
+)
+
+// seatAvailability считает свободные места класса fareConditions на каждом рейсе:
+// места салона самолёта минус проданные билеты неотменённых сегментов (или выданные
+// посадочные, если их больше)
+// и минус действующие удержания мест. Удержание бронирования holdGUID не вычитается —
+// его места и подтверждаются.
+func seatAvailability(tx *gorm.DB, flightIDs []uint, fareConditions, holdGUID string) (map[uint]int, error) {
+	available := make(map[uint]int, len(flightIDs))
+	if len(flightIDs) == 0 {
+		return available, nil
+	}
+
+	var rows []struct {
+		FlightID       uint
+		SeatsAvailable int
+	}
+	if err := tx.Raw(`
+        SELECT f.flight_id,
+               (SELECT COUNT(*) FROM seats s
+                WHERE s.aircraft_code = f.aircraft_code AND s.fare_conditions = @fare)
+               - GREATEST(
+                   (SELECT COUNT(*) FROM ticket_flights tf
+                    WHERE tf.flight_id = f.flight_id AND tf.fare_conditions = @fare
+                    AND NOT EXISTS (
+                        SELECT 1 FROM books b
+                        JOIN booking_segments bs ON bs.guid = b.guid AND bs.flight_id = b.flight_id
+                        WHERE b.ticket_no = tf.ticket_no AND b.flight_id = tf.flight_id
+                        AND bs.status = @cancelled)),
+                   (SELECT COUNT(*) FROM boarding_passes bp
+                    JOIN seats s ON s.aircraft_code = f.aircraft_code AND s.seat_no = bp.seat_no
+                    WHERE bp.flight_id = f.flight_id AND s.fare_conditions = @fare)
+               )
+               - (SELECT COALESCE(SUM(h.seats), 0) FROM seat_holds h
+                  WHERE h.flight_id = f.flight_id AND h.fare_conditions = @fare
+                  AND h.status = @active AND h.expires_at > now() AND h.guid <> @hold_guid)
+               AS seats_available
+        FROM flights f
+        WHERE f.flight_id IN @ids`,
+		map[string]interface{}{
+			"ids":       flightIDs,
+			"fare":      fareConditions,
+			"active":    models.HoldStatusActive,
+			"cancelled": models.BookStatusCancelled,
+			"hold_guid": holdGUID,
+		}).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		available[row.FlightID] = max(row.SeatsAvailable, 0)
+	}
+	return available, nil
+}
@@ -8,30 +8,45 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
-	"math/rand"
 	"net/http"
+	"slices"
 	"strconv"
-	"strings"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	_ "github.com/AntonTsoy/airflight-service/docs"
+	"github.com/AntonTsoy/airflight-service/internal/bcbp"
 	"github.com/AntonTsoy/airflight-service/internal/config"
 	"github.com/AntonTsoy/airflight-service/internal/models"
+	"github.com/AntonTsoy/airflight-service/internal/payment"
+	"github.com/AntonTsoy/airflight-service/internal/routing"
+	"github.com/AntonTsoy/airflight-service/internal/seats"
+	"github.com/AntonTsoy/airflight-service/internal/ticketno"
 )
 
-var db *gorm.DB
+var (
+	db            *gorm.DB
+	cfg           *config.Config
+	flightIndex   *routing.Index
+	ticketNumbers ticketno.Generator
+	payments      payment.PaymentProvider
+)
+
+var scheduleSortKeys = []string{"time", "flight_no", "airport"}
 
 func enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		if r.Method == "OPTIONS" {
@@ -43,33 +58,35 @@ func enableCORS(next http.Handler) http.Handler {
 	})
 }
 
-func generateTicketNo() string {
-	const charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, 13)
-	for i := range b {
-		b[i] = charset[rand.Intn(len(charset))]
-	}
-	return string(b)
-}
-
 // @Summary Get all cities
 // @Description Retrieve a list of all cities from the database
 // @Tags cities
 // @Accept json
 // @Produce json
-// @Success 200 {array} string
+// @Param limit query int false "Page size (1-500); default 50"
+// @Param cursor query string false "Cursor from next_cursor of the previous page"
+// @Success 200 {object} models.Page[string]
+// @Failure 400 {string} string "Invalid pagination parameters"
 // @Failure 500 {object} map[string]string
 // @Router /cities [get]
 func getCities(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageParams(r, []string{"city"})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	var cities []string
-	result := db.Model(&models.Airport{}).Distinct("city").Pluck("city", &cities)
+	result := db.Model(&models.Airport{}).Distinct("city").Order("city").
+		Offset(page.Offset).Limit(page.Limit+1).
+		Pluck("city", &cities)
 	if result.Error != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(cities)
+	json.NewEncoder(w).Encode(newPage(cities, page))
 }
 
 // @Summary Get all airports
@@ -78,17 +95,29 @@ func getCities(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param city query string false "Airport city"
-// @Success 200 {array} Airport
+// @Param sort query string false "Sort order: airport_code (default), city or airport_name"
+// @Param limit query int false "Page size (1-500); default 50"
+// @Param cursor query string false "Cursor from next_cursor of the previous page"
+// @Success 200 {object} models.Page[models.Airport]
+// @Failure 400 {string} string "Invalid pagination parameters"
 // @Failure 500 {object} map[string]string
 // @Router /airports [get]
 func getAirports(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageParams(r, []string{"airport_code", "city", "airport_name"})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	var airports []models.Airport
 	result := db.Distinct()
 	city := r.URL.Query().Get("city")
 	if city != "" {
 		result = result.Where("city LIKE ?", city)
 	}
-	result = result.Find(&airports)
+	result = result.Order(page.Sort).Order("airport_code").
+		Offset(page.Offset).Limit(page.Limit + 1).
+		Find(&airports)
 
 	if result.Error != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
@@ -96,7 +125,7 @@ func getAirports(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(airports)
+	json.NewEncoder(w).Encode(newPage(airports, page))
 }
 
 // @Summary Get inbound schedule for an airport
@@ -104,8 +133,11 @@ func getAirports(w http.ResponseWriter, r *http.Request) {
 // @Tags airports
 // @Produce json
 // @Param airport_code path string true "Airport code"
-// @Success 200 {array} FlightSchedule
-// @Failure 400 {string} ErrorResponse "Missing or invalid airport code"
+// @Param sort query string false "Sort order: time (default), flight_no or airport"
+// @Param limit query int false "Page size (1-500); default 50"
+// @Param cursor query string false "Cursor from next_cursor of the previous page"
+// @Success 200 {object} models.Page[models.FlightSchedule]
+// @Failure 400 {string} string "Missing or invalid airport code or pagination parameters"
 // @Failure 500 {object} map[string]string
 // @Router /airports/{airport_code}/inbound-schedule [get]
 func getInboundScheduleAirport(w http.ResponseWriter, r *http.Request) {
@@ -115,17 +147,36 @@ func getInboundScheduleAirport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	page, err := parsePageParams(r, scheduleSortKeys)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	orderBy := map[string]string{"time": "scheduled_arrival", "flight_no": "flight_no", "airport": "departure_airport"}[page.Sort]
+
 	var flights []models.Flight
-	if err := db.Where("arrival_airport = ?", airportCode).Find(&flights).Error; err != nil {
+	if err := db.Where("arrival_airport = ?", airportCode).
+		Order(orderBy).Order("flight_id").
+		Offset(page.Offset).Limit(page.Limit + 1).
+		Find(&flights).Error; err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	zone, err := airportZone(airportCode)
+	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
 	schedules := make([]models.FlightSchedule, 0, len(flights))
 	for _, flight := range flights {
+		local := flight.ScheduledArrival.In(zone)
 		schedule := models.FlightSchedule{
-			DayOfWeek:     flight.ScheduledArrival.Weekday().String(),
-			TimeOfArrival: flight.ScheduledArrival.Format("15:04"),
+			DayOfWeek:     local.Weekday().String(),
+			TimeOfArrival: local.Format("15:04"),
+			UTCOffset:     local.Format("-07:00"),
+			Timezone:      zone.String(),
 			FlightNo:      flight.FlightNo,
 			OriginAirport: flight.DepartureAirport,
 		}
@@ -134,7 +185,7 @@ func getInboundScheduleAirport(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(schedules)
+	json.NewEncoder(w).Encode(newPage(schedules, page))
 }
 
 // @Summary Get outbound schedule for an airport
@@ -142,8 +193,11 @@ func getInboundScheduleAirport(w http.ResponseWriter, r *http.Request) {
 // @Tags airports
 // @Produce json
 // @Param airport_code path string true "Airport code"
-// @Success 200 {array} FlightSchedule
-// @Failure 400 {string} ErrorResponse "Missing or invalid airport code"
+// @Param sort query string false "Sort order: time (default), flight_no or airport"
+// @Param limit query int false "Page size (1-500); default 50"
+// @Param cursor query string false "Cursor from next_cursor of the previous page"
+// @Success 200 {object} models.Page[models.FlightSchedule]
+// @Failure 400 {string} string "Missing or invalid airport code or pagination parameters"
 // @Failure 500 {object} map[string]string
 // @Router /airports/{airport_code}/outbound-schedule [get]
 func getOutboundScheduleAirport(w http.ResponseWriter, r *http.Request) {
@@ -153,17 +207,36 @@ func getOutboundScheduleAirport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	page, err := parsePageParams(r, scheduleSortKeys)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	orderBy := map[string]string{"time": "scheduled_departure", "flight_no": "flight_no", "airport": "arrival_airport"}[page.Sort]
+
 	var flights []models.Flight
-	if err := db.Where("departure_airport = ?", airportCode).Find(&flights).Error; err != nil {
+	if err := db.Where("departure_airport = ?", airportCode).
+		Order(orderBy).Order("flight_id").
+		Offset(page.Offset).Limit(page.Limit + 1).
+		Find(&flights).Error; err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	zone, err := airportZone(airportCode)
+	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
 	schedules := make([]models.FlightSchedule, 0, len(flights))
 	for _, flight := range flights {
+		local := flight.ScheduledDeparture.In(zone)
 		schedule := models.FlightSchedule{
-			DayOfWeek:     flight.ScheduledDeparture.Weekday().String(),
-			TimeOfArrival: flight.ScheduledDeparture.Format("15:04"),
+			DayOfWeek:     local.Weekday().String(),
+			TimeOfArrival: local.Format("15:04"),
+			UTCOffset:     local.Format("-07:00"),
+			Timezone:      zone.String(),
 			FlightNo:      flight.FlightNo,
 			OriginAirport: flight.ArrivalAirport,
 		}
@@ -172,313 +245,1111 @@ func getOutboundScheduleAirport(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(schedules)
+	json.NewEncoder(w).Encode(newPage(schedules, page))
 }
 
 // @Summary Book a route
-// @Description Idempotent booking of flights with a GUID
+// @Description Idempotent booking of flights with a GUID for one or more passengers; one ticket per passenger per flight.
+// @Description An active seat hold for the same GUID is confirmed and must match the requested flights and fare.
+// @Description The total is charged through the payment provider before tickets are issued.
 // @Tags bookings
 // @Accept json
 // @Produce json
 // @Param guid path string true "GUID"
-// @Param booking body BookingRequest true "Booking data"
-// @Success 200 {array} TicketFlight "Existing or new tickets"
-// @Failure 400 {string}  map[string]string
-// @Failure 500 {string}  map[string]string
+// @Param booking body models.BookingRequest true "Booking data"
+// @Success 200 {array} models.PassengerTickets "Existing or new tickets grouped by passenger"
+// @Failure 400 {object} models.ErrorResponse "Invalid input"
+// @Failure 404 {object} models.ErrorResponse "Flight not found"
+// @Failure 402 {object} models.ErrorResponse "Payment declined"
+// @Failure 409 {object} models.ErrorResponse "GUID already used for a different request, hold mismatch, fares changed, or a flight is cancelled, departed or sold out"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Router /bookings/{guid} [put]
 func bookRoute(w http.ResponseWriter, r *http.Request) {
 	guid := chi.URLParam(r, "guid")
 	if guid == "" {
-		http.Error(w, "Missing guid parameter", http.StatusBadRequest)
+		writeError(w, invalidInput("Missing guid parameter"))
 		return
 	}
 
 	defer r.Body.Close()
 	var req models.BookingRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Failed to decode input", http.StatusBadRequest)
+		writeError(w, invalidInput("Failed to decode input"))
 		return
 	}
 
-	validClasses := map[string]bool{"Economy": true, "Comfort": true, "Business": true, "EconomySec": true}
-	if !validClasses[req.FareConditions] {
-		http.Error(w, "Invalid fare condition. Must be 'Economy', 'Comfort', 'Business', or 'EconomySec'", http.StatusBadRequest)
+	if !validFareConditions[req.FareConditions] {
+		writeError(w, invalidInput("Invalid fare condition. Must be 'Economy', 'Comfort', 'Business', or 'EconomySec'"))
+		return
+	}
+	if len(req.FlightIDs) == 0 {
+		writeError(w, invalidInput("At least one flight ID is required"))
+		return
+	}
+	if err := normalizeBookingRequest(&req); err != nil {
+		writeError(w, invalidInput(err.Error()))
 		return
 	}
 
-	var tickets []models.TicketFlight
-	err := db.Transaction(func(tx *gorm.DB) error {
-		var existingBooks []models.Book
-		if err := tx.Where("guid = ?", guid).Find(&existingBooks).Error; err != nil {
+	fingerprint := requestFingerprint(req)
+
+	// Оплата авторизуется до транзакции, чтобы не держать блокировки рейсов на время
+	// обращения к провайдеру; в транзакции она только списывается.
+	var authorizedPayment string
+	var authorizedAmount float64
+	existing, err := findBooking(db, guid)
+	if err != nil {
+		writeError(w, internalError("Failed to process booking in DB"))
+		return
+	}
+	if existing == nil {
+		amount, priced, err := bookingAmount(req)
+		if err == nil && priced {
+			authorizedAmount = amount
+			authorizedPayment, err = authorizePayment(guid, amount)
+		}
+		if err != nil {
+			writeFailure(w, err, "Failed to authorize payment")
+			return
+		}
+	}
+
+	var groups []models.PassengerTickets
+	captured := false
+	err = db.Transaction(func(tx *gorm.DB) error {
+		existing, err := findBooking(tx, guid)
+		if err != nil {
 			return err
 		}
 
-		if len(existingBooks) > 0 {
-			var ticketNos []string
-			for _, book := range existingBooks {
-				ticketNos = append(ticketNos, book.TicketNo)
+		if existing != nil {
+			if conflict := replayConflict(req, fingerprint, existing); conflict != nil {
+				return conflict
 			}
-			if err := tx.Where("ticket_no IN ?", ticketNos).Find(&tickets).Error; err != nil {
+			tickets, err := issuedTickets(tx, existing)
+			if err != nil {
 				return err
 			}
+			groups = groupTickets(existing, tickets)
+			return nil
+		}
+
+		now := time.Now()
+		prices, err := lockBookableFlights(tx, req.FlightIDs, req.FareConditions, len(req.Passengers), guid, now)
+		if err != nil {
+			return err
+		}
+		if err := confirmHold(tx, guid, req, now); err != nil {
+			return err
+		}
+
+		// Все билеты всех пассажиров создаются в одной транзакции: бронь либо целиком, либо никак.
+		record, err := createBooking(tx, guid, req, fingerprint, prices, now)
+		if err != nil {
+			return err
+		}
+		if captured, err = chargeBooking(tx, record, authorizedPayment, authorizedAmount, now); err != nil {
+			return err
+		}
+		tickets, err := issueTickets(tx, record, req.FlightIDs, req.FareConditions, prices)
+		if err != nil {
+			return err
+		}
+		groups = groupTickets(record, tickets)
+		return nil
+	})
+
+	if authorizedPayment != "" {
+		switch {
+		case !captured:
+			// Повтор уже созданного бронирования или отказ до списания — снимаем авторизацию.
+			voidPayment(authorizedPayment)
+		case err != nil:
+			// Оплата списана, но бронирование не сохранилось — возвращаем деньги.
+			refundPayment(authorizedPayment, authorizedAmount)
+		}
+	}
+	if err != nil {
+		writeFailure(w, err, "Failed to process booking in DB")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(groups)
+}
+
+// @Summary Hold seats before booking
+// @Description Reserves seats of a fare class on the given flights for the hold TTL; confirm with PUT /bookings/{guid}.
+// @Description Held seats are assigned to concrete seat numbers and shown as held on the seat map.
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Param guid path string true "Booking GUID"
+// @Param hold body models.HoldRequest true "Flights, fare conditions and number of passengers"
+// @Success 200 {object} models.Hold "Existing or new hold"
+// @Failure 400 {object} models.ErrorResponse "Invalid input"
+// @Failure 404 {object} models.ErrorResponse "Flight not found"
+// @Failure 409 {object} models.ErrorResponse "Booking or different hold exists, or a flight is not bookable"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /bookings/{guid}/hold [post]
+func holdSeats(w http.ResponseWriter, r *http.Request) {
+	guid := chi.URLParam(r, "guid")
+	if guid == "" {
+		writeError(w, invalidInput("Missing guid parameter"))
+		return
+	}
+
+	defer r.Body.Close()
+	var req models.HoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, invalidInput("Failed to decode input"))
+		return
+	}
+	if !validFareConditions[req.FareConditions] {
+		writeError(w, invalidInput("Invalid fare condition. Must be 'Economy', 'Comfort', 'Business', or 'EconomySec'"))
+		return
+	}
+	if len(req.FlightIDs) == 0 {
+		writeError(w, invalidInput("At least one flight ID is required"))
+		return
+	}
+	if req.Passengers == 0 {
+		req.Passengers = 1
+	}
+	if req.Passengers < 1 || req.Passengers > maxPassengers {
+		writeError(w, invalidInput(fmt.Sprintf("passengers must be between 1 and %d", maxPassengers)))
+		return
+	}
+
+	var hold models.Hold
+	err := db.Transaction(func(tx *gorm.DB) error {
+		existing, err := findBooking(tx, guid)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return &httpError{
+				Status:  http.StatusConflict,
+				Code:    "booking_exists",
+				Message: fmt.Sprintf("booking %s is already confirmed", guid),
+			}
+		}
+
+		now := time.Now()
+		if _, err := lockBookableFlights(tx, req.FlightIDs, req.FareConditions, req.Passengers, guid, now); err != nil {
+			return err
+		}
+
+		holds, err := findActiveHold(tx, guid, now)
+		if err != nil {
+			return err
+		}
+		if len(holds) > 0 {
+			if details := holdMismatch(holds, req.FareConditions, req.FlightIDs, req.Passengers); len(details) > 0 {
+				return &httpError{
+					Status:  http.StatusConflict,
+					Code:    "hold_exists",
+					Message: fmt.Sprintf("a different seat hold already exists for GUID %s", guid),
+					Details: details,
+				}
+			}
+			hold = newHold(guid, holds)
 			return nil
 		}
 
+		// Просроченные строки удержания с тем же GUID заменяются новыми.
+		if err := tx.Where("guid = ? AND status <> ?", guid, models.HoldStatusConfirmed).
+			Delete(&models.SeatHold{}).Error; err != nil {
+			return err
+		}
+		expiresAt := now.Add(cfg.HoldTTL)
 		for _, flightID := range req.FlightIDs {
-			ticketNo := generateTicketNo()
-			book := models.Book{
+			holds = append(holds, models.SeatHold{
 				GUID:           guid,
 				FlightID:       flightID,
 				FareConditions: req.FareConditions,
-				TicketNo:       ticketNo,
-				Passanger:      req.Passanger,
+				Seats:          req.Passengers,
+				Status:         models.HoldStatusActive,
+				CreatedAt:      now,
+				ExpiresAt:      expiresAt,
+			})
+		}
+		if err := tx.Create(&holds).Error; err != nil {
+			return err
+		}
+		for _, h := range holds {
+			if err := assignHoldSeats(tx, h, now); err != nil {
+				return err
 			}
-			ticketFlight := models.TicketFlight{
-				TicketNo:       ticketNo,
-				FlightID:       flightID,
-				FareConditions: req.FareConditions,
+		}
+		slices.SortFunc(holds, func(a, b models.SeatHold) int { return int(a.FlightID) - int(b.FlightID) })
+		hold = newHold(guid, holds)
+		return nil
+	})
+
+	if err != nil {
+		writeFailure(w, err, "Failed to hold seats in DB")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(hold)
+}
+
+// @Summary Get a booking
+// @Description Returns the booking for a GUID with flights, tickets and boarding passes
+// @Tags bookings
+// @Produce json
+// @Param guid path string true "Booking GUID"
+// @Success 200 {object} models.Booking
+// @Failure 400 {object} models.ErrorResponse "Missing guid"
+// @Failure 404 {object} models.ErrorResponse "Booking not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /bookings/{guid} [get]
+func getBooking(w http.ResponseWriter, r *http.Request) {
+	guid := chi.URLParam(r, "guid")
+	if guid == "" {
+		writeError(w, invalidInput("Missing guid parameter"))
+		return
+	}
+
+	record, err := findBooking(db, guid)
+	if err != nil {
+		writeError(w, internalError("Database error"))
+		return
+	}
+	if record == nil {
+		writeError(w, &httpError{
+			Status:  http.StatusNotFound,
+			Code:    "booking_not_found",
+			Message: fmt.Sprintf("booking not found for GUID %s", guid),
+		})
+		return
+	}
+
+	booking, err := loadBooking(record)
+	if err != nil {
+		writeError(w, internalError("Database error"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(booking)
+}
+
+// @Summary Change booked flights or fare conditions
+// @Description Moves every passenger of a segment to another flight between the same cities and/or to another fare class in one transaction.
+// @Description The new flight must keep the minimum connection time to the neighbouring segments of the booking.
+// @Description Inventory is re-checked, boarding passes of changed segments are voided and the fare difference
+// @Description is charged or refunded through the payment provider.
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Param guid path string true "Booking GUID"
+// @Param changes body models.BookingChangeRequest true "Segment changes"
+// @Success 200 {object} models.BookingChangeResult
+// @Failure 400 {object} models.ErrorResponse "Invalid input"
+// @Failure 402 {object} models.ErrorResponse "Payment of the fare difference declined"
+// @Failure 404 {object} models.ErrorResponse "Booking, segment or flight not found"
+// @Failure 409 {object} models.ErrorResponse "Flight departed, cancelled, sold out, already booked, on another route or out of itinerary order (route_mismatch), or fares changed (price_changed)"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /bookings/{guid} [patch]
+func changeBookingFlights(w http.ResponseWriter, r *http.Request) {
+	guid := chi.URLParam(r, "guid")
+	if guid == "" {
+		writeError(w, invalidInput("Missing guid parameter"))
+		return
+	}
+
+	defer r.Body.Close()
+	var req models.BookingChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, invalidInput("Failed to decode input"))
+		return
+	}
+
+	// Доплата авторизуется до транзакции, как при бронировании, чтобы не обращаться
+	// к провайдеру под блокировками рейсов; в транзакции она только списывается.
+	var authorizedPayment string
+	var authorizedAmount float64
+	current, err := findBooking(db, guid)
+	if err != nil {
+		writeError(w, internalError("Failed to change booking in DB"))
+		return
+	}
+	estimate := models.BookingChangeRequest{Changes: slices.Clone(req.Changes)}
+	if current != nil && current.Header.Status != models.BookStatusCancelled &&
+		validateChangeRequest(&estimate, current) == nil {
+		amount, err := changeAmount(current, estimate.Changes)
+		if err == nil && amount > 0 {
+			authorizedAmount = amount
+			authorizedPayment, err = authorizePayment(guid, amount)
+		}
+		if err != nil {
+			writeFailure(w, err, "Failed to authorize payment")
+			return
+		}
+	}
+
+	var result models.BookingChangeResult
+	var refunds []plannedRefund
+	err = db.Transaction(func(tx *gorm.DB) error {
+		record, err := lockBooking(tx, guid)
+		if err != nil {
+			return err
+		}
+		if record == nil || record.Header.Status == models.BookStatusCancelled {
+			return &httpError{
+				Status:  http.StatusNotFound,
+				Code:    "booking_not_found",
+				Message: fmt.Sprintf("no active booking found for GUID %s", guid),
 			}
+		}
+		if err := validateChangeRequest(&req, record); err != nil {
+			return err
+		}
 
-			if err := tx.Create(&book).Error; err != nil {
-				return err
+		result, refunds, err = changeBooking(tx, record, req, authorizedPayment, authorizedAmount, time.Now())
+		return err
+	})
+
+	if authorizedPayment != "" {
+		switch {
+		case result.PaymentID == "":
+			// Доплата не понадобилась или изменение отклонено до списания — снимаем авторизацию.
+			voidPayment(authorizedPayment)
+		case err != nil:
+			// Доплата списана, но изменение не сохранилось — возвращаем деньги.
+			refundPayment(result.PaymentID, result.TotalDifference)
+		}
+	}
+	if err != nil {
+		writeFailure(w, err, "Failed to change booking in DB")
+		return
+	}
+	result.Refunded, result.RefundPending = settleRefunds(refunds)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// @Summary Cancel a booking
+// @Description Cancels every active segment of the booking, releases the seats and refunds through the payment provider
+// @Description (never more than was charged; refund_pending is the part the provider could not process)
+// @Tags bookings
+// @Produce json
+// @Param guid path string true "Booking GUID"
+// @Param force query bool false "Cancel even if a boarding pass was issued (the pass is voided)"
+// @Success 200 {object} models.CancellationResult
+// @Failure 400 {object} models.ErrorResponse "Invalid input"
+// @Failure 404 {object} models.ErrorResponse "Booking not found"
+// @Failure 409 {object} models.ErrorResponse "Boarding pass issued or flight departed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /bookings/{guid} [delete]
+func cancelBooking(w http.ResponseWriter, r *http.Request) {
+	guid := chi.URLParam(r, "guid")
+	if guid == "" {
+		writeError(w, invalidInput("Missing guid parameter"))
+		return
+	}
+	writeCancellation(w, guid, nil, r.URL.Query().Get("force") == "true")
+}
+
+// @Summary Cancel a booked flight
+// @Description Cancels one segment of the booking, releases its seats and refunds through the payment provider
+// @Tags bookings
+// @Produce json
+// @Param guid path string true "Booking GUID"
+// @Param flight_id path uint true "Flight ID"
+// @Param force query bool false "Cancel even if a boarding pass was issued (the pass is voided)"
+// @Success 200 {object} models.CancellationResult
+// @Failure 400 {object} models.ErrorResponse "Invalid input"
+// @Failure 404 {object} models.ErrorResponse "Booking not found"
+// @Failure 409 {object} models.ErrorResponse "Boarding pass issued or flight departed"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /bookings/{guid}/flights/{flight_id} [delete]
+func cancelBookingFlight(w http.ResponseWriter, r *http.Request) {
+	guid := chi.URLParam(r, "guid")
+	flightID, err := strconv.ParseUint(chi.URLParam(r, "flight_id"), 10, 0)
+	if guid == "" || err != nil {
+		writeError(w, invalidInput("GUID and Fligth ID are required"))
+		return
+	}
+	id := uint(flightID)
+	writeCancellation(w, guid, &id, r.URL.Query().Get("force") == "true")
+}
+
+func writeCancellation(w http.ResponseWriter, guid string, flightID *uint, force bool) {
+	var result models.CancellationResult
+	var refunds []plannedRefund
+	err := db.Transaction(func(tx *gorm.DB) error {
+		record, err := lockBooking(tx, guid)
+		if err != nil {
+			return err
+		}
+		var segments []models.BookingSegment
+		if record != nil {
+			for _, segment := range record.Segments {
+				if segment.Status == models.BookStatusActive && (flightID == nil || segment.FlightID == *flightID) {
+					segments = append(segments, segment)
+				}
 			}
-			if err := tx.Create(&ticketFlight).Error; err != nil {
-				return err
+		}
+		if len(segments) == 0 {
+			return &httpError{
+				Status:  http.StatusNotFound,
+				Code:    "booking_not_found",
+				Message: fmt.Sprintf("no active booking found for GUID %s", guid),
 			}
-			tickets = append(tickets, ticketFlight)
 		}
 
-		return nil
+		result, refunds, err = cancelSegments(tx, record, segments, force, time.Now())
+		return err
 	})
 
 	if err != nil {
-		http.Error(w, "Failed to process booking in DB", http.StatusInternalServerError)
+		writeFailure(w, err, "Failed to cancel booking in DB")
 		return
 	}
+	// Отмена уже зафиксирована: возврат, который провайдер не провёл, остаётся отложенным.
+	_, result.RefundPending = settleRefunds(refunds)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(tickets)
+	json.NewEncoder(w).Encode(result)
 }
 
 // @Summary Check-in for a flight
-// @Description Assigns a seat for a booked flight using a GUID
+// @Description Assigns the requested seat or, without seat_no, the frontmost free seat of the fare class
+// @Description (window first, then aisle, unless seat_preference says otherwise)
 // @Tags bookings
 // @Accept json
 // @Produce json
 // @Param guid path string true "Booking GUID"
 // @Param flight_id path uint true "Flight ID"
-// @Success 200 {object} BoardingPass "Boarding pass details"
-// @Failure 400 {string} ErrorResponse "Invalid input"
-// @Failure 404 {string} ErrorResponse "Booking or seat not found"
-// @Failure 500 {string} ErrorResponse "Internal server error"
+// @Param passenger_no query int false "Passenger number; required when the booking has several passengers"
+// @Param seat body models.CheckInRequest false "Requested seat or seat preference"
+// @Success 200 {object} models.BoardingPass "Boarding pass details with IATA BCBP barcode data"
+// @Failure 400 {object} models.ErrorResponse "Invalid input, or the seat is not on the aircraft or of another fare class"
+// @Failure 404 {object} models.ErrorResponse "Booking or seat not found"
+// @Failure 409 {object} models.ErrorResponse "Seat taken or held (seat_held), seat or boarding number conflict (checkin_conflict), already checked in to another seat, flight cancelled, or check-in not open (checkin_too_early) or closed (checkin_closed)"
+// @Failure 503 {object} models.ErrorResponse "Check-in kept conflicting with concurrent check-ins"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Router /bookings/{guid}/check-in/{flight_id} [put]
 func checkIn(w http.ResponseWriter, r *http.Request) {
 	guid := chi.URLParam(r, "guid")
 	flight_id, err := strconv.ParseUint(chi.URLParam(r, "flight_id"), 10, 0)
 	if guid == "" || err != nil {
-		http.Error(w, "GUID and Fligth ID are required", http.StatusBadRequest)
+		writeError(w, invalidInput("GUID and Fligth ID are required"))
 		return
 	}
 	reqFligthId := uint(flight_id)
+	passengerNo := 0
+	if v := r.URL.Query().Get("passenger_no"); v != "" {
+		if passengerNo, err = strconv.Atoi(v); err != nil || passengerNo < 1 {
+			writeError(w, invalidInput("passenger_no must be a positive number"))
+			return
+		}
+	}
+
+	defer r.Body.Close()
+	var req models.CheckInRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, invalidInput("Failed to decode input"))
+		return
+	}
+	if req.SeatNo != "" {
+		if _, _, err := seats.Parse(req.SeatNo); err != nil {
+			writeError(w, invalidInput("seat_no must be a row number followed by a seat letter, e.g. 12A"))
+			return
+		}
+	}
+	if !validSeatPreferences[req.SeatPreference] {
+		writeError(w, invalidInput("seat_preference must be 'window' or 'aisle'"))
+		return
+	}
 
 	var boardingPass models.BoardingPass
-	err = db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("ticket_no IN (?) AND flight_id = ?",
-			tx.Table("books").Select("ticket_no").Where("guid = ? AND flight_id = ?", guid, reqFligthId),
-			reqFligthId).First(&boardingPass).Error; err == nil {
-			return nil // посадочный талон уже существует, возвращаем его
-		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("failed to check existing boarding pass: %v", err)
+	err = retryTransaction(checkInAttempts, func(tx *gorm.DB) error {
+		// Регистрации на один рейс выполняются по очереди: номер посадочного и свободное
+		// место выбираются под блокировкой строки рейса.
+		var flight models.Flight
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("flight_id = ?", reqFligthId).First(&flight).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return checkInNotFound(guid, reqFligthId)
+			}
+			return fmt.Errorf("failed to lock flight: %w", err)
 		}
 
-		var book models.Book
-		if err := tx.Where("guid = ? AND flight_id = ?", guid, reqFligthId).First(&book).Error; err != nil {
+		var segment models.BookingSegment
+		if err := tx.Where("guid = ? AND flight_id = ? AND status = ?", guid, reqFligthId, models.BookStatusActive).
+			First(&segment).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return fmt.Errorf("booking not found for GUID %s and flight ID %d", guid, reqFligthId)
+				return checkInNotFound(guid, reqFligthId)
 			}
-			return fmt.Errorf("failed to find booking: %v", err)
+			return fmt.Errorf("failed to find booking: %w", err)
 		}
 
-		var seat models.Seat
-		subQuery := tx.Table("boarding_passes").Select("seat_no").Where("flight_id = ?", reqFligthId)
-		if err := tx.Table("flights f").
-			Select("s.seat_no").
-			Joins("JOIN seats s ON s.aircraft_code = f.aircraft_code").
-			Where("f.flight_id = ? AND s.fare_conditions = ?", reqFligthId, book.FareConditions).
-			Where("s.seat_no NOT IN (?)", subQuery).
-			Limit(1).
-			Find(&seat).Error; err != nil {
-			return fmt.Errorf("failed to find available seat: %v", err)
+		query := tx.Where("guid = ? AND flight_id = ?", guid, reqFligthId)
+		if passengerNo > 0 {
+			query = query.Where("passenger_no = ?", passengerNo)
+		}
+		var books []models.Book
+		if err := query.Find(&books).Error; err != nil {
+			return fmt.Errorf("failed to find booking: %w", err)
+		}
+		if len(books) == 0 {
+			return checkInNotFound(guid, reqFligthId)
+		}
+		if len(books) > 1 {
+			return flightError(http.StatusBadRequest, "passenger_no_required", reqFligthId,
+				fmt.Sprintf("passenger_no is required: booking has %d passengers on flight %d", len(books), reqFligthId))
 		}
+		book := books[0]
 
-		if seat.SeatNo == "" {
-			return fmt.Errorf("no available seats for fare condition %s on flight %d", book.FareConditions, reqFligthId)
+		if err := tx.Where("ticket_no = ? AND flight_id = ?", book.TicketNo, reqFligthId).First(&boardingPass).Error; err == nil {
+			if req.SeatNo != "" && req.SeatNo != boardingPass.SeatNo {
+				return flightError(http.StatusConflict, "already_checked_in", reqFligthId,
+					fmt.Sprintf("ticket %s is already checked in to seat %s", book.TicketNo, boardingPass.SeatNo))
+			}
+			return nil // посадочный талон уже существует, возвращаем его
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to check existing boarding pass: %w", err)
+		}
+
+		// Окно регистрации проверяется только для новых талонов: выданный талон можно получить повторно.
+		now := time.Now()
+		if err := checkInAllowed(flight, now); err != nil {
+			return err
+		}
+
+		seatNo, err := assignSeat(tx, reqFligthId, segment.FareConditions, req, now)
+		if err != nil {
+			return err
 		}
 
 		var maxBoardingNo struct{ Max int }
-		tx.Table("boarding_passes").
+		if err := tx.Table("boarding_passes").
 			Select("COALESCE(MAX(boarding_no), 0) as max").
 			Where("flight_id = ?", reqFligthId).
-			Scan(&maxBoardingNo)
+			Scan(&maxBoardingNo).Error; err != nil {
+			return fmt.Errorf("failed to compute boarding number: %w", err)
+		}
 
 		boardingPass = models.BoardingPass{
 			TicketNo:   book.TicketNo,
 			FlightID:   reqFligthId,
 			BoardingNo: maxBoardingNo.Max + 1,
-			SeatNo:     seat.SeatNo,
+			SeatNo:     seatNo,
 		}
 		if err := tx.Create(&boardingPass).Error; err != nil {
-			return fmt.Errorf("failed to create boarding pass: %v", err)
+			return fmt.Errorf("failed to create boarding pass: %w", err)
 		}
 
 		return nil
 	})
 
 	if err != nil {
-		var status = 400
-		if strings.Contains(err.Error(), "booking not found") || strings.Contains(err.Error(), "no available seats") {
-			status = http.StatusNotFound
-		} else {
-			status = http.StatusInternalServerError
+		if retryableError(err) {
+			writeError(w, flightError(http.StatusServiceUnavailable, "checkin_busy", reqFligthId,
+				fmt.Sprintf("too many concurrent check-ins for flight %d, try again", reqFligthId)))
+			return
+		}
+		if uniqueViolation(err) {
+			writeError(w, flightError(http.StatusConflict, "checkin_conflict", reqFligthId,
+				fmt.Sprintf("seat or boarding number on flight %d is already taken", reqFligthId)))
+			return
 		}
-		http.Error(w, err.Error(), status)
+		writeFailure(w, err, "Failed to check in")
 		return
 	}
 
+	// Талон уже выдан, поэтому без штрихкода он всё равно возвращается.
+	boardingPass.BCBP, err = boardingPassBCBP(db, boardingPass.TicketNo, boardingPass.FlightID, boardingPass.SeatNo, boardingPass.BoardingNo)
+	if err != nil {
+		log.Printf("failed to build BCBP for ticket %s, flight %d: %v", boardingPass.TicketNo, boardingPass.FlightID, err)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(boardingPass)
 }
 
+// @Summary Get a boarding pass
+// @Description Returns the boarding pass of a ticket with its IATA BCBP barcode data
+// @Tags bookings
+// @Produce json
+// @Param ticket_no path string true "Ticket number (13 digits)"
+// @Param flight_id query uint false "Flight ID; required when the ticket has boarding passes for several flights"
+// @Success 200 {object} models.BoardingPass
+// @Failure 400 {object} models.ErrorResponse "Invalid ticket number"
+// @Failure 404 {object} models.ErrorResponse "Boarding pass not found"
+// @Failure 422 {object} models.ErrorResponse "Passenger name or other data cannot be encoded in the barcode (bcbp_invalid)"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /boarding-passes/{ticket_no} [get]
+func getBoardingPass(w http.ResponseWriter, r *http.Request) {
+	ticketNo := chi.URLParam(r, "ticket_no")
+	if err := ticketno.Validate(ticketNo, cfg.TicketPrefix); err != nil {
+		writeError(w, &httpError{Status: http.StatusBadRequest, Code: "invalid_ticket_no", Message: err.Error()})
+		return
+	}
+
+	query := db.Where("ticket_no = ?", ticketNo)
+	if v := r.URL.Query().Get("flight_id"); v != "" {
+		flightID, err := strconv.ParseUint(v, 10, 0)
+		if err != nil {
+			writeError(w, invalidInput("flight_id must be a positive number"))
+			return
+		}
+		query = query.Where("flight_id = ?", flightID)
+	}
+	var passes []models.BoardingPass
+	if err := query.Order("flight_id").Find(&passes).Error; err != nil {
+		writeError(w, internalError("Database error"))
+		return
+	}
+	switch {
+	case len(passes) == 0:
+		writeError(w, &httpError{
+			Status:  http.StatusNotFound,
+			Code:    "boarding_pass_not_found",
+			Message: fmt.Sprintf("no boarding pass found for ticket %s", ticketNo),
+		})
+		return
+	case len(passes) > 1:
+		writeError(w, invalidInput(fmt.Sprintf("flight_id is required: ticket %s has %d boarding passes", ticketNo, len(passes))))
+		return
+	}
+
+	pass := passes[0]
+	var err error
+	if pass.BCBP, err = boardingPassBCBP(db, pass.TicketNo, pass.FlightID, pass.SeatNo, pass.BoardingNo); err != nil {
+		if errors.Is(err, bcbp.ErrInvalid) {
+			writeError(w, &httpError{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "bcbp_invalid",
+				Message: fmt.Sprintf("boarding pass data for ticket %s cannot be encoded in a barcode: %v", ticketNo, err),
+			})
+			return
+		}
+		writeError(w, internalError("Failed to build boarding pass barcode"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(pass)
+}
+
+// @Summary Get the seat map of a flight
+// @Description Lists every seat of the flight's aircraft grouped by fare conditions and row.
+// @Description Each seat is free, held (reserved by an active seat hold) or occupied (has a boarding pass).
+// @Description Tickets sold without a boarding pass are not tied to a seat and are counted per cabin as seats_unassigned.
+// @Tags flights
+// @Produce json
+// @Param flight_id path uint true "Flight ID"
+// @Success 200 {object} models.SeatMap
+// @Failure 400 {object} models.ErrorResponse "Invalid input"
+// @Failure 404 {object} models.ErrorResponse "Flight not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /flights/{flight_id}/seat-map [get]
+func getSeatMap(w http.ResponseWriter, r *http.Request) {
+	flightID, err := strconv.ParseUint(chi.URLParam(r, "flight_id"), 10, 0)
+	if err != nil {
+		writeError(w, invalidInput("Fligth ID is required"))
+		return
+	}
+
+	var flight models.Flight
+	if err := db.Where("flight_id = ?", flightID).First(&flight).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, flightError(http.StatusNotFound, "flight_not_found", uint(flightID), fmt.Sprintf("flight %d does not exist", flightID)))
+			return
+		}
+		writeError(w, internalError("Database error"))
+		return
+	}
+
+	seatMap, err := buildSeatMap(flight)
+	if err != nil {
+		writeError(w, internalError("Database error"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(seatMap)
+}
+
 // @Summary Get routes between two points
-// @Description Lists routes connecting two points (airport or city) with specified filters
+// @Description Lists itineraries connecting two points (airport or city) with up to the given number of connections
 // @Tags routes
 // @Produce json
 // @Param from query string true "Departure point (airport code or city)"
 // @Param to query string true "Arrival point (airport code or city)"
-// @Param departure_date query string true "Departure date (YYYY-MM-DD)"
+// @Param departure_date query string true "Departure date (YYYY-MM-DD) in the local time of the departure airport"
 // @Param booking_class query string true "Booking class (Economy, Comfort, Business)"
-// @Param connections query int false "Number of connections (0, 1, 2, 3); default 0"
-// @Success 200 {array} Route "List of routes"
-// @Failure 400 {string} ErrorResponse "Invalid input"
-// @Failure 500 {string} ErrorResponse "Internal server error"
+// @Param connections query int false "Maximum number of connections (0, 1, 2, 3); default 0"
+// @Param min_connection query int false "Minimum connection time in minutes; airport MCT still applies"
+// @Param max_connection query int false "Maximum connection time in minutes"
+// @Param passengers query int false "Number of passengers that must fit on every leg; default 1"
+// @Param max_price query number false "Maximum total price of the itinerary for the booking class"
+// @Param sort query string false "Sort order: departure (default), arrival, duration, price or stops"
+// @Param limit query int false "Page size (1-500); default 50"
+// @Param cursor query string false "Cursor from next_cursor of the previous page"
+// @Success 200 {object} models.Page[models.Route] "Page of itineraries with ordered legs and layovers"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 500 {string} string "Internal server error"
 // @Router /routes [get]
 func getRoutes(w http.ResponseWriter, r *http.Request) {
 	from := r.URL.Query().Get("from")
 	to := r.URL.Query().Get("to")
 	departureDateStr := r.URL.Query().Get("departure_date")
 	bookingClass := r.URL.Query().Get("booking_class")
-	connectionsStr := r.URL.Query().Get("connections")
 
 	if from == "" || to == "" || departureDateStr == "" || bookingClass == "" {
 		http.Error(w, "From, to, departure_date, and booking_class are required", http.StatusBadRequest)
 		return
 	}
-	validClasses := map[string]bool{"Economy": true, "EconomySec": true, "Comfort": true, "Business": true}
-	if !validClasses[bookingClass] {
-		http.Error(w, "Invalid booking class!", http.StatusBadRequest)
+
+	query, err := parseRouteQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	connections := 0
-	if connectionsStr != "" {
-		if c, err := strconv.Atoi(connectionsStr); err == nil && c >= 0 {
-			connections = c
-		} else {
-			http.Error(w, "Connections must be 0, 1, 2, or greeter", http.StatusBadRequest)
-			return
-		}
+	page, err := parsePageParams(r, routeSortKeys)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	query.Sort = page.Sort
 
-	departureDate, err := time.Parse("2006-01-02", departureDateStr)
+	departureDate, err := time.Parse(dateLayout, departureDateStr)
 	if err != nil {
 		http.Error(w, "Invalid departure date format. Use YYYY-MM-DD", http.StatusBadRequest)
 		return
 	}
-	nextDate := departureDate.Add(24 * time.Hour)
+	query.DateFrom, query.DateTo = departureDate, departureDate
 
-	var fromAirports, toAirports []models.Airport
-	if err := db.Where("city = ? OR airport_code = ?", from, from).Find(&fromAirports).Error; err != nil {
+	if query.FromCodes, err = resolvePoint(from); err != nil {
 		http.Error(w, "Failed to fetch 'from' airports", http.StatusInternalServerError)
 		return
 	}
-	if err := db.Where("city = ? OR airport_code = ?", to, to).Find(&toAirports).Error; err != nil {
+	if query.ToCodes, err = resolvePoint(to); err != nil {
 		http.Error(w, "Failed to fetch 'to' airports", http.StatusInternalServerError)
 		return
 	}
-	if len(fromAirports) == 0 || len(toAirports) == 0 {
+	if len(query.FromCodes) == 0 || len(query.ToCodes) == 0 {
 		http.Error(w, "No airports found for given points", http.StatusNotFound)
 		return
 	}
 
-	var routes []models.Route
-	fromCodes := make([]string, len(fromAirports))
-	for i, airport := range fromAirports {
-		fromCodes[i] = airport.AirportCode
+	routes, err := searchRoutes(query)
+	if err != nil {
+		http.Error(w, "Failed to fetch flights", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newPage(pageWindow(routes, page), page))
+}
+
+// @Summary Search round-trip and multi-city routes
+// @Description Searches itineraries for every segment of a trip; return_date turns a single segment into a round trip.
+// @Description Every segment returns its own page of itineraries; pass its next_cursor at the same index of cursors to get the next page.
+// @Tags routes
+// @Accept json
+// @Produce json
+// @Param search body models.TripSearchRequest true "Trip segments and filters"
+// @Success 200 {object} models.TripSearchResult "Itineraries per segment"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 404 {string} string "Unknown departure or arrival point"
+// @Failure 500 {string} string "Internal server error"
+// @Router /routes/search [post]
+func searchTrips(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var req models.TripSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to decode input", http.StatusBadRequest)
+		return
+	}
+
+	segments := req.Segments
+	if req.ReturnDate != "" {
+		if len(segments) != 1 {
+			http.Error(w, "return_date requires exactly one segment", http.StatusBadRequest)
+			return
+		}
+		segments = append(segments, models.TripSegment{
+			From:          segments[0].To,
+			To:            segments[0].From,
+			DepartureDate: req.ReturnDate,
+		})
 	}
-	toCodes := make([]string, len(toAirports))
-	for i, airport := range toAirports {
-		toCodes[i] = airport.AirportCode
+	if len(segments) == 0 || len(segments) > maxTripSegments {
+		http.Error(w, fmt.Sprintf("Trip must have between 1 and %d segments", maxTripSegments), http.StatusBadRequest)
+		return
 	}
-	if connections == 0 {
-		var flights []models.Flight
-		if err := db.Where("departure_airport IN ? AND arrival_airport IN ? AND scheduled_departure BETWEEN ? AND ?",
-			fromCodes, toCodes, departureDate, nextDate).Find(&flights).Error; err != nil {
-			http.Error(w, "Failed to fetch flights", http.StatusInternalServerError)
+
+	base := routeQuery{
+		Connections:    req.Connections,
+		MinConnection:  cfg.MinConnectionTime,
+		MaxConnection:  cfg.MaxConnectionTime,
+		FareConditions: req.BookingClass,
+		Passengers:     req.Passengers,
+		MaxPrice:       req.MaxPrice,
+		Sort:           req.Sort,
+	}
+	if req.MinConnection != nil {
+		base.MinConnection = time.Duration(*req.MinConnection) * time.Minute
+	}
+	if req.MaxConnection != nil {
+		base.MaxConnection = time.Duration(*req.MaxConnection) * time.Minute
+	}
+	if base.Passengers == 0 {
+		base.Passengers = 1
+	}
+	if base.Sort == "" {
+		base.Sort = routeSortKeys[0]
+	}
+	if err := base.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultPageLimit
+	}
+	if limit < 1 || limit > maxPageLimit {
+		http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxPageLimit), http.StatusBadRequest)
+		return
+	}
+	if len(req.Cursors) > len(segments) {
+		http.Error(w, "cursors must not outnumber the trip segments", http.StatusBadRequest)
+		return
+	}
+	pages := make([]pageParams, len(segments))
+	for i := range pages {
+		pages[i] = pageParams{Sort: base.Sort, Limit: limit}
+		if i < len(req.Cursors) {
+			if err := pages[i].setCursor(req.Cursors[i]); err != nil {
+				http.Error(w, fmt.Sprintf("Segment %d: %v", i+1, err), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	queries := make([]routeQuery, len(segments))
+	for i, segment := range segments {
+		if segment.From == "" || segment.To == "" || segment.DepartureDate == "" {
+			http.Error(w, fmt.Sprintf("Segment %d: from, to and departure_date are required", i+1), http.StatusBadRequest)
 			return
 		}
-		for _, flight := range flights {
-			routes = append(routes, models.Route{
-				FlightNo:           flight.FlightNo,
-				DepartureAirport:   flight.DepartureAirport,
-				ArrivalAirport:     flight.ArrivalAirport,
-				ScheduledDeparture: flight.ScheduledDeparture,
-				ScheduledArrival:   flight.ScheduledArrival,
-			})
+		date, err := time.Parse(dateLayout, segment.DepartureDate)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Segment %d: invalid departure date format. Use YYYY-MM-DD", i+1), http.StatusBadRequest)
+			return
+		}
+		if i > 0 && date.Before(queries[i-1].DateFrom) {
+			http.Error(w, fmt.Sprintf("Segment %d departs before segment %d", i+1, i), http.StatusBadRequest)
+			return
+		}
+
+		q := base
+		q.DateFrom, q.DateTo = date, date
+		if q.FromCodes, err = resolvePoint(segment.From); err != nil {
+			http.Error(w, "Failed to fetch 'from' airports", http.StatusInternalServerError)
+			return
+		}
+		if q.ToCodes, err = resolvePoint(segment.To); err != nil {
+			http.Error(w, "Failed to fetch 'to' airports", http.StatusInternalServerError)
+			return
 		}
+		if len(q.FromCodes) == 0 || len(q.ToCodes) == 0 {
+			http.Error(w, fmt.Sprintf("Segment %d: no airports found for given points", i+1), http.StatusNotFound)
+			return
+		}
+		queries[i] = q
 	}
 
-	if connections >= 1 {
-		var connectingFlights []models.Flight
-		if err := db.Raw(`
-            SELECT f1.flight_no, f1.departure_airport, f2.arrival_airport, f1.scheduled_departure, f2.scheduled_arrival
-            FROM flights f1
-            JOIN flights f2 ON f1.arrival_airport = f2.departure_airport
-            WHERE f1.departure_airport IN ? AND f2.arrival_airport IN ?
-            AND f1.scheduled_departure BETWEEN ? AND ?
-            AND f2.scheduled_departure > f1.scheduled_arrival
-            AND f2.scheduled_departure < f1.scheduled_arrival + INTERVAL '24 hours'`,
-			fromCodes, toCodes, departureDate, nextDate).Scan(&connectingFlights).Error; err != nil {
+	result := models.TripSearchResult{Segments: make([]models.TripSegmentResult, 0, len(segments))}
+	var earliestNext time.Time
+	for i, q := range queries {
+		routes, err := searchRoutes(q)
+		if err != nil {
 			http.Error(w, "Failed to fetch flights", http.StatusInternalServerError)
 			return
 		}
-		for _, flight := range connectingFlights {
-			routes = append(routes, models.Route{
-				FlightNo:           flight.FlightNo,
-				DepartureAirport:   flight.DepartureAirport,
-				ArrivalAirport:     flight.ArrivalAirport,
-				ScheduledDeparture: flight.ScheduledDeparture,
-				ScheduledArrival:   flight.ScheduledArrival,
-			})
+
+		// Маршрут следующего сегмента должен вылетать не раньше, чем можно успеть
+		// прилететь по самому раннему маршруту предыдущего.
+		feasible := routes[:0]
+		for _, route := range routes {
+			if route.ScheduledDeparture.Before(earliestNext) {
+				continue
+			}
+			feasible = append(feasible, route)
+		}
+		routes = feasible
+		earliestNext = time.Time{}
+		for _, route := range routes {
+			arrival := route.ScheduledArrival.Add(q.MinConnection)
+			if earliestNext.IsZero() || arrival.Before(earliestNext) {
+				earliestNext = arrival
+			}
 		}
+
+		result.Segments = append(result.Segments, models.TripSegmentResult{
+			From:          segments[i].From,
+			To:            segments[i].To,
+			DepartureDate: segments[i].DepartureDate,
+			Routes:        newPage(pageWindow(routes, pages[i]), pages[i]),
+		})
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(routes)
+	json.NewEncoder(w).Encode(result)
+}
+
+// @Summary Get a fare calendar between two points
+// @Description For every day of the range returns whether any itinerary exists and its cheapest total fare
+// @Tags routes
+// @Produce json
+// @Param from query string true "Departure point (airport code or city)"
+// @Param to query string true "Arrival point (airport code or city)"
+// @Param date_from query string true "First departure date (YYYY-MM-DD), local time of the departure airport"
+// @Param date_to query string true "Last departure date (YYYY-MM-DD), at most 31 days after date_from"
+// @Param booking_class query string true "Booking class (Economy, Comfort, Business)"
+// @Param connections query int false "Maximum number of connections (0, 1, 2, 3); default 0"
+// @Param min_connection query int false "Minimum connection time in minutes; airport MCT still applies"
+// @Param max_connection query int false "Maximum connection time in minutes"
+// @Param passengers query int false "Number of passengers that must fit on every leg; default 1"
+// @Success 200 {array} models.CalendarDay "One entry per day of the range"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 404 {string} string "Unknown departure or arrival point"
+// @Failure 500 {string} string "Internal server error"
+// @Router /routes/calendar [get]
+func getRouteCalendar(w http.ResponseWriter, r *http.Request) {
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	dateFromStr := r.URL.Query().Get("date_from")
+	dateToStr := r.URL.Query().Get("date_to")
+	bookingClass := r.URL.Query().Get("booking_class")
+
+	if from == "" || to == "" || dateFromStr == "" || dateToStr == "" || bookingClass == "" {
+		http.Error(w, "From, to, date_from, date_to and booking_class are required", http.StatusBadRequest)
+		return
+	}
+
+	query, err := parseRouteQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if query.DateFrom, err = time.Parse(dateLayout, dateFromStr); err != nil {
+		http.Error(w, "Invalid date_from format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if query.DateTo, err = time.Parse(dateLayout, dateToStr); err != nil {
+		http.Error(w, "Invalid date_to format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	days := int(query.DateTo.Sub(query.DateFrom).Hours()/24) + 1
+	if days < 1 || days > maxCalendarDays {
+		http.Error(w, fmt.Sprintf("Date range must cover between 1 and %d days", maxCalendarDays), http.StatusBadRequest)
+		return
+	}
+
+	if query.FromCodes, err = resolvePoint(from); err != nil {
+		http.Error(w, "Failed to fetch 'from' airports", http.StatusInternalServerError)
+		return
+	}
+	if query.ToCodes, err = resolvePoint(to); err != nil {
+		http.Error(w, "Failed to fetch 'to' airports", http.StatusInternalServerError)
+		return
+	}
+	if len(query.FromCodes) == 0 || len(query.ToCodes) == 0 {
+		http.Error(w, "No airports found for given points", http.StatusNotFound)
+		return
+	}
+
+	// Весь диапазон ищется одним запросом, маршруты раскладываются по местной дате вылета.
+	routes, err := searchRoutes(query)
+	if err != nil {
+		http.Error(w, "Failed to fetch flights", http.StatusInternalServerError)
+		return
+	}
+
+	calendar := make([]models.CalendarDay, days)
+	index := make(map[string]int, days)
+	for i := range calendar {
+		date := query.DateFrom.AddDate(0, 0, i).Format(dateLayout)
+		calendar[i] = models.CalendarDay{Date: date}
+		index[date] = i
+	}
+	for _, route := range routes {
+		i, ok := index[route.ScheduledDeparture.Format(dateLayout)]
+		if !ok {
+			continue
+		}
+		day := &calendar[i]
+		if !day.Available || route.TotalPrice < *day.MinPrice {
+			price := route.TotalPrice
+			day.MinPrice = &price
+		}
+		day.Available = true
+		day.Routes++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(calendar)
 }
 
 func main() {
-	config, err := config.Load()
+	var err error
+	cfg, err = config.Load()
 	if err != nil {
 		panic(err)
 	}
 
-	db, err = gorm.Open(postgres.Open(config.DatabaseDSN), &gorm.Config{})
+	db, err = gorm.Open(postgres.Open(cfg.DatabaseDSN), &gorm.Config{})
 	if err != nil {
 		log.Fatal("failed to connect to database:", err)
 	}
 
+	ticketNumbers, err = ticketno.NewSequenceGenerator(cfg.TicketPrefix, "ticket_no_seq")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch cfg.PaymentProvider {
+	case "fake":
+		payments = payment.NewFake()
+	default:
+		log.Fatalf("unknown payment provider %q", cfg.PaymentProvider)
+	}
+
+	if cfg.HoldSweepInterval > 0 {
+		go runHoldSweeper(cfg.HoldSweepInterval, nil)
+	}
+
+	if cfg.RouteIndexRefresh > 0 {
+		flightIndex = routing.NewIndex(db)
+		if err := flightIndex.Refresh(); err != nil {
+			log.Println("failed to load flight index, falling back to SQL route search:", err)
+		}
+		go flightIndex.Run(cfg.RouteIndexRefresh, nil)
+	}
+
 	r := chi.NewRouter()
 	r.Use(enableCORS)
 	r.Use(middleware.Logger)
@@ -487,11 +1358,20 @@ func main() {
 	r.Get("/airports/{airport_code}/inbound-schedule", getInboundScheduleAirport)
 	r.Get("/airports/{airport_code}/outbound-schedule", getOutboundScheduleAirport)
 	r.Get("/cities", getCities)
+	r.Get("/flights/{flight_id}/seat-map", getSeatMap)
 	r.Get("/routes", getRoutes)
+	r.Post("/routes/search", searchTrips)
+	r.Get("/routes/calendar", getRouteCalendar)
+	r.Get("/bookings/{guid}", getBooking)
 	r.Put("/bookings/{guid}", bookRoute)
+	r.Patch("/bookings/{guid}", changeBookingFlights)
+	r.Post("/bookings/{guid}/hold", holdSeats)
+	r.Delete("/bookings/{guid}", cancelBooking)
+	r.Delete("/bookings/{guid}/flights/{flight_id}", cancelBookingFlight)
 	r.Put("/bookings/{guid}/check-in/{flight_id}", checkIn)
+	r.Get("/boarding-passes/{ticket_no}", getBoardingPass)
 	r.Get("/swagger/*", httpSwagger.Handler(httpSwagger.URL("/swagger/doc.json")))
 
-	fmt.Printf("Listening on http://%s/swagger/\n", config.ListenAddr)
-	http.ListenAndServe(config.ListenAddr, r)
+	fmt.Printf("Listening on http://%s/swagger/\n", cfg.ListenAddr)
+	http.ListenAndServe(cfg.ListenAddr, r)
 }
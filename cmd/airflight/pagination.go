@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/AntonTsoy/airflight-service/internal/models"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+type pageParams struct {
+	Sort   string
+	Limit  int
+	Offset int
+}
+
+// parsePageParams разбирает общие для всех списков параметры sort, limit и cursor.
+// Курсор непрозрачен для клиента: в нём закодированы порядок сортировки и смещение,
+// поэтому продолжать выдачу с другим sort нельзя.
+func parsePageParams(r *http.Request, sorts []string) (pageParams, error) {
+	p := pageParams{Sort: sorts[0], Limit: defaultPageLimit}
+
+	if sort := r.URL.Query().Get("sort"); sort != "" {
+		valid := false
+		for _, s := range sorts {
+			valid = valid || s == sort
+		}
+		if !valid {
+			return p, fmt.Errorf("invalid sort. Must be one of: %s", strings.Join(sorts, ", "))
+		}
+		p.Sort = sort
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return p, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		p.Limit = limit
+	}
+
+	if err := p.setCursor(r.URL.Query().Get("cursor")); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+// setCursor продолжает выдачу с позиции курсора; пустой курсор означает первую страницу.
+func (p *pageParams) setCursor(cursor string) error {
+	if cursor == "" {
+		return nil
+	}
+	sort, offset, err := decodeCursor(cursor)
+	if err != nil {
+		return fmt.Errorf("invalid cursor")
+	}
+	if sort != p.Sort {
+		return fmt.Errorf("cursor was issued for sort %q", sort)
+	}
+	p.Offset = offset
+	return nil
+}
+
+func encodeCursor(sort string, offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(sort + ":" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (string, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, err
+	}
+	sort, offsetStr, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return "", 0, fmt.Errorf("malformed cursor")
+	}
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		return "", 0, fmt.Errorf("malformed cursor")
+	}
+	return sort, offset, nil
+}
+
+// newPage ожидает items, начинающиеся со смещения p.Offset, и не более p.Limit+1 элементов:
+// лишний элемент означает, что есть следующая страница.
+func newPage[T any](items []T, p pageParams) models.Page[T] {
+	page := models.Page[T]{Items: items}
+	if len(items) > p.Limit {
+		page.Items = items[:p.Limit]
+		page.NextCursor = encodeCursor(p.Sort, p.Offset+p.Limit)
+	}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	return page
+}
+
+// pageWindow вырезает из полностью загруженного списка окно для newPage.
+func pageWindow[T any](items []T, p pageParams) []T {
+	if p.Offset >= len(items) {
+		return nil
+	}
+	return items[p.Offset:min(p.Offset+p.Limit+1, len(items))]
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/AntonTsoy/airflight-service/internal/models"
+	"github.com/AntonTsoy/airflight-service/internal/payment"
+)
+
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// authorizePayment блокирует amount у плательщика бронирования guid. Отказ провайдера
+// отдаётся клиенту как 402.
+func authorizePayment(guid string, amount float64) (string, error) {
+	paymentID, err := payments.Authorize(guid, amount)
+	if errors.Is(err, payment.ErrDeclined) {
+		return "", &httpError{
+			Status:  http.StatusPaymentRequired,
+			Code:    "payment_declined",
+			Message: fmt.Sprintf("payment of %.2f for booking %s was declined", amount, guid),
+		}
+	}
+	return paymentID, err
+}
+
+// voidPayment снимает несписанную авторизацию; ошибка только логируется — авторизация
+// в любом случае истечёт у провайдера.
+func voidPayment(paymentID string) {
+	if err := payments.Void(paymentID); err != nil {
+		log.Printf("failed to void payment %s: %v", paymentID, err)
+	}
+}
+
+// refundPayment возвращает списанную сумму, если бронирование не удалось сохранить.
+func refundPayment(paymentID string, amount float64) {
+	if err := payments.Refund(paymentID, amount); err != nil {
+		log.Printf("failed to refund payment %s: %v", paymentID, err)
+	}
+}
+
+// capturePayment списывает заранее авторизованную оплату paymentID и записывает платёж.
+// Авторизованная сумма должна совпадать с amount: тариф мог измениться после авторизации.
+// Возвращает true, как только оплата списана, даже если запись не удалась: вызывающий должен
+// вернуть деньги, если транзакция не зафиксируется.
+func capturePayment(tx *gorm.DB, guid, paymentID string, authorized, amount float64, now time.Time) (bool, error) {
+	if paymentID == "" || roundMoney(authorized) != roundMoney(amount) {
+		return false, &httpError{
+			Status:  http.StatusConflict,
+			Code:    "price_changed",
+			Message: fmt.Sprintf("fares changed while booking %s; repeat the request", guid),
+		}
+	}
+	if err := payments.Capture(paymentID, amount); err != nil {
+		return false, err
+	}
+	return true, recordPayment(tx, guid, paymentID, amount, now)
+}
+
+func recordPayment(tx *gorm.DB, guid, paymentID string, amount float64, now time.Time) error {
+	return tx.Create(&models.BookingPayment{
+		PaymentID: paymentID,
+		GUID:      guid,
+		Amount:    amount,
+		CreatedAt: now,
+	}).Error
+}
+
+func bookingPayments(tx *gorm.DB, guid string) ([]models.BookingPayment, error) {
+	var list []models.BookingPayment
+	if err := tx.Where("guid = ?", guid).Order("created_at DESC, payment_id DESC").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// plannedRefund — часть возврата по одному платежу.
+type plannedRefund struct {
+	PaymentID string
+	Amount    float64
+}
+
+// allocateRefund распределяет amount по списаниям бронирования начиная с последнего, но не
+// больше, чем по ним списано и ещё не возвращено.
+func allocateRefund(list []models.BookingPayment, amount float64) []plannedRefund {
+	var refunds []plannedRefund
+	left := roundMoney(amount)
+	for _, p := range list {
+		if left <= 0 {
+			break
+		}
+		available := roundMoney(p.Amount - p.Refunded - p.RefundPending)
+		if available <= 0 {
+			continue
+		}
+		part := math.Min(left, available)
+		left = roundMoney(left - part)
+		refunds = append(refunds, plannedRefund{PaymentID: p.PaymentID, Amount: part})
+	}
+	return refunds
+}
+
+// planRefunds записывает возврат amount в refund_pending списаний paid (см. allocateRefund). Провайдер
+// вызывается только после фиксации транзакции (settleRefunds): если бы деньги вернулись
+// в транзакции, а она откатилась, повтор запроса вернул бы их ещё раз.
+func planRefunds(tx *gorm.DB, paid []models.BookingPayment, amount float64) ([]plannedRefund, error) {
+	refunds := allocateRefund(paid, amount)
+	for _, r := range refunds {
+		if err := tx.Model(&models.BookingPayment{}).Where("payment_id = ?", r.PaymentID).
+			Update("refund_pending", gorm.Expr("refund_pending + ?", r.Amount)).Error; err != nil {
+			return nil, err
+		}
+	}
+	return refunds, nil
+}
+
+func plannedTotal(refunds []plannedRefund) float64 {
+	var total float64
+	for _, r := range refunds {
+		total += r.Amount
+	}
+	return roundMoney(total)
+}
+
+// refundThroughProvider проводит возвраты через провайдера и возвращает проведённые.
+// Непроведённые (провайдер не знает платежа — например, имитация после перезапуска — или
+// отказал) остаются в refund_pending для ручного возврата; их сумма — pending.
+func refundThroughProvider(refunds []plannedRefund) (done []plannedRefund, pending float64) {
+	for _, r := range refunds {
+		if err := payments.Refund(r.PaymentID, r.Amount); err != nil {
+			log.Printf("refund of %.2f for payment %s needs manual processing: %v", r.Amount, r.PaymentID, err)
+			pending += r.Amount
+			continue
+		}
+		done = append(done, r)
+	}
+	return done, roundMoney(pending)
+}
+
+// settleRefunds вызывается после фиксации транзакции, записавшей возвраты planRefunds:
+// проводит их через провайдера и переносит проведённые суммы из refund_pending в refunded.
+// Возвращает проведённую и отложенную суммы.
+func settleRefunds(refunds []plannedRefund) (float64, float64) {
+	done, pending := refundThroughProvider(refunds)
+	for _, r := range done {
+		if err := db.Model(&models.BookingPayment{}).Where("payment_id = ?", r.PaymentID).
+			Updates(map[string]interface{}{
+				"refunded":       gorm.Expr("refunded + ?", r.Amount),
+				"refund_pending": gorm.Expr("refund_pending - ?", r.Amount),
+			}).Error; err != nil {
+			log.Printf("refund of %.2f for payment %s was made but not recorded: %v", r.Amount, r.PaymentID, err)
+		}
+	}
+	return plannedTotal(done), pending
+}
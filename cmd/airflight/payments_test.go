@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/AntonTsoy/airflight-service/internal/models"
+	"github.com/AntonTsoy/airflight-service/internal/payment"
+)
+
+func TestAllocateRefund(t *testing.T) {
+	// Списания в порядке bookingPayments: сначала последнее.
+	paid := []models.BookingPayment{
+		{PaymentID: "surcharge", Amount: 3000},
+		{PaymentID: "refunded", Amount: 500, Refunded: 500},
+		{PaymentID: "booking", Amount: 10000, Refunded: 1000, RefundPending: 500.5},
+	}
+
+	tests := []struct {
+		name   string
+		amount float64
+		want   []plannedRefund
+	}{
+		{"within the last payment", 1200, []plannedRefund{{"surcharge", 1200}}},
+		{"spread over payments", 5000.25, []plannedRefund{{"surcharge", 3000}, {"booking", 2000.25}}},
+		{"capped at what is left", 20000, []plannedRefund{{"surcharge", 3000}, {"booking", 8499.5}}},
+		{"nothing to refund", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := allocateRefund(paid, tt.amount)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("allocateRefund(%.2f) = %v, want %v", tt.amount, got, tt.want)
+			}
+		})
+	}
+
+	if got := allocateRefund(nil, 100); got != nil {
+		t.Errorf("allocateRefund without payments = %v, want nil", got)
+	}
+}
+
+func TestRefundThroughProvider(t *testing.T) {
+	fake := payment.NewFake()
+	payments = fake
+
+	captured, _ := fake.Authorize("guid-1", 100)
+	if err := fake.Capture(captured, 100); err != nil {
+		t.Fatal(err)
+	}
+	authorized, _ := fake.Authorize("guid-1", 50)
+
+	refunds := []plannedRefund{
+		{PaymentID: captured, Amount: 70},
+		// Платёж до перезапуска имитации ей неизвестен.
+		{PaymentID: "fake-before-restart", Amount: 20.1},
+		// Несписанный платёж вернуть нельзя.
+		{PaymentID: authorized, Amount: 10},
+		// Больше, чем осталось после первого возврата.
+		{PaymentID: captured, Amount: 30.01},
+	}
+	done, pending := refundThroughProvider(refunds)
+	if want := []plannedRefund{{captured, 70}}; !slices.Equal(done, want) {
+		t.Errorf("done = %v, want %v", done, want)
+	}
+	if pending != 60.11 {
+		t.Errorf("pending = %.2f, want 60.11", pending)
+	}
+	if p, _ := fake.Get(captured); p.Refunded != 70 {
+		t.Errorf("refunded by the provider = %.2f, want 70", p.Refunded)
+	}
+	if total := plannedTotal(done); total != 70 {
+		t.Errorf("plannedTotal(done) = %.2f, want 70", total)
+	}
+}
+
+func TestCapturePaymentPriceChanged(t *testing.T) {
+	fake := payment.NewFake()
+	payments = fake
+	id, _ := fake.Authorize("guid-1", 100)
+
+	tests := []struct {
+		name       string
+		paymentID  string
+		authorized float64
+	}{
+		{"not authorized", "", 0},
+		{"authorized for another amount", id, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captured, err := capturePayment(nil, "guid-1", tt.paymentID, tt.authorized, 120, time.Now())
+			var he *httpError
+			if captured || !errors.As(err, &he) || he.Status != http.StatusConflict || he.Code != "price_changed" {
+				t.Errorf("capturePayment() = %v, %v; want false, 409 price_changed", captured, err)
+			}
+		})
+	}
+	if p, _ := fake.Get(id); p.Status != payment.StatusAuthorized {
+		t.Errorf("payment status = %s, want it left authorized for the caller to void", p.Status)
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AntonTsoy/airflight-service/internal/models"
+	"github.com/AntonTsoy/airflight-service/internal/routing"
+)
+
+const (
+	maxConnections  = 3
+	maxPassengers   = 9
+	maxTripSegments = 6
+	maxCalendarDays = 31
+	dateLayout      = routing.DateLayout
+)
+
+type routeQuery struct {
+	FromCodes []string
+	ToCodes   []string
+	// Календарные даты вылета (включительно) по местному времени аэропорта отправления.
+	DateFrom    time.Time
+	DateTo      time.Time
+	Connections int
+	// Границы пересадки; минимальное время дополнительно поднимается до MCT аэропорта пересадки.
+	MinConnection time.Duration
+	MaxConnection time.Duration
+
+	FareConditions string
+	Passengers     int
+	// MaxPrice ограничивает суммарную стоимость маршрута; 0 — без ограничения.
+	MaxPrice float64
+	Sort     string
+}
+
+var validFareConditions = map[string]bool{"Economy": true, "EconomySec": true, "Comfort": true, "Business": true}
+
+// parseRouteQuery разбирает фильтры поиска из query-параметров GET /routes.
+// Точки и даты заполняет вызывающий код.
+func parseRouteQuery(r *http.Request) (routeQuery, error) {
+	q := routeQuery{
+		FareConditions: r.URL.Query().Get("booking_class"),
+		MinConnection:  cfg.MinConnectionTime,
+		MaxConnection:  cfg.MaxConnectionTime,
+		Passengers:     1,
+	}
+
+	values := r.URL.Query()
+	if v := values.Get("connections"); v != "" {
+		c, err := strconv.Atoi(v)
+		if err != nil {
+			return q, fmt.Errorf("Connections must be between 0 and %d", maxConnections)
+		}
+		q.Connections = c
+	}
+	if v := values.Get("min_connection"); v != "" {
+		m, err := strconv.Atoi(v)
+		if err != nil {
+			return q, errors.New("min_connection must be a non-negative number of minutes")
+		}
+		q.MinConnection = time.Duration(m) * time.Minute
+	}
+	if v := values.Get("max_connection"); v != "" {
+		m, err := strconv.Atoi(v)
+		if err != nil {
+			return q, errors.New("max_connection must be a positive number of minutes")
+		}
+		q.MaxConnection = time.Duration(m) * time.Minute
+	}
+	if v := values.Get("max_price"); v != "" {
+		p, err := strconv.ParseFloat(v, 64)
+		if err != nil || p <= 0 {
+			return q, errors.New("max_price must be a positive number")
+		}
+		q.MaxPrice = p
+	}
+	if v := values.Get("passengers"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil {
+			return q, fmt.Errorf("passengers must be between 1 and %d", maxPassengers)
+		}
+		q.Passengers = p
+	}
+	return q, q.validate()
+}
+
+func (q routeQuery) validate() error {
+	switch {
+	case !validFareConditions[q.FareConditions]:
+		return errors.New("Invalid booking class!")
+	case q.Connections < 0 || q.Connections > maxConnections:
+		return fmt.Errorf("Connections must be between 0 and %d", maxConnections)
+	case q.MinConnection < 0:
+		return errors.New("min_connection must be a non-negative number of minutes")
+	case q.MaxConnection <= 0:
+		return errors.New("max_connection must be a positive number of minutes")
+	case q.MinConnection > q.MaxConnection:
+		return errors.New("min_connection must not exceed max_connection")
+	case q.Passengers < 1 || q.Passengers > maxPassengers:
+		return fmt.Errorf("passengers must be between 1 and %d", maxPassengers)
+	case q.MaxPrice < 0:
+		return errors.New("max_price must be a positive number")
+	}
+	if _, ok := routeSorts[q.Sort]; q.Sort != "" && !ok {
+		return fmt.Errorf("invalid sort. Must be one of: %s", strings.Join(routeSortKeys, ", "))
+	}
+	return nil
+}
+
+// resolvePoint возвращает коды аэропортов по коду аэропорта или названию города.
+func resolvePoint(point string) ([]string, error) {
+	var airports []models.Airport
+	if err := db.Where("city = ? OR airport_code = ?", point, point).Find(&airports).Error; err != nil {
+		return nil, err
+	}
+	return airportCodes(airports), nil
+}
+
+var routeSortKeys = []string{"departure", "arrival", "duration", "price", "stops"}
+
+var routeSorts = map[string]func(a, b models.Route) bool{
+	"departure": func(a, b models.Route) bool {
+		return a.ScheduledDeparture.Before(b.ScheduledDeparture)
+	},
+	"arrival": func(a, b models.Route) bool {
+		return a.ScheduledArrival.Before(b.ScheduledArrival)
+	},
+	"duration": func(a, b models.Route) bool {
+		return a.TravelMinutes < b.TravelMinutes
+	},
+	"price": func(a, b models.Route) bool {
+		return a.TotalPrice < b.TotalPrice
+	},
+	"stops": func(a, b models.Route) bool {
+		return a.Stops < b.Stops
+	},
+}
+
+// routeFinder выбирает источник цепочек рейсов: индекс в памяти, если он загружен,
+// иначе рекурсивный запрос к базе.
+func routeFinder() routing.Finder {
+	if flightIndex != nil && flightIndex.Ready() {
+		return flightIndex
+	}
+	return routing.SQLFinder{DB: db}
+}
+
+func (q routeQuery) pathQuery() routing.Query {
+	return routing.Query{
+		From:          q.FromCodes,
+		To:            q.ToCodes,
+		DateFrom:      q.DateFrom,
+		DateTo:        q.DateTo,
+		MaxStops:      q.Connections,
+		MinConnection: q.MinConnection,
+		MaxConnection: q.MaxConnection,
+	}
+}
+
+func searchRoutes(q routeQuery) ([]models.Route, error) {
+	paths, err := routeFinder().Paths(q.pathQuery())
+	if err != nil {
+		return nil, err
+	}
+
+	var flightIDs []uint
+	for _, path := range paths {
+		flightIDs = append(flightIDs, path...)
+	}
+	flightsByID, err := loadFlights(flightIDs)
+	if err != nil {
+		return nil, err
+	}
+	zones, err := airportZones()
+	if err != nil {
+		return nil, err
+	}
+	prices, err := flightPrices(db, flightIDs, q.FareConditions)
+	if err != nil {
+		return nil, err
+	}
+	available, err := seatAvailability(db, flightIDs, q.FareConditions, "")
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]models.Route, 0, len(paths))
+nextPath:
+	for _, path := range paths {
+		flights := make([]models.Flight, 0, len(path))
+		for _, id := range path {
+			if _, ok := prices[id]; !ok {
+				continue nextPath // класс не продаётся на одном из перелётов
+			}
+			if available[id] < q.Passengers {
+				continue nextPath
+			}
+			flights = append(flights, flightsByID[id])
+		}
+		route := newRoute(flights, zones, prices)
+		for i := range route.Legs {
+			route.Legs[i].SeatsAvailable = available[route.Legs[i].FlightID]
+		}
+		if q.MaxPrice > 0 && route.TotalPrice > q.MaxPrice {
+			continue
+		}
+		route.FareConditions = q.FareConditions
+		routes = append(routes, route)
+	}
+
+	sortRoutes(routes, q.Sort)
+	return routes, nil
+}
+
+// sortRoutes упорядочивает маршруты по ключу sort, при равенстве — по времени вылета и длительности.
+func sortRoutes(routes []models.Route, key string) {
+	less, ok := routeSorts[key]
+	if !ok {
+		less = routeSorts["departure"]
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		if less(routes[i], routes[j]) {
+			return true
+		}
+		if less(routes[j], routes[i]) {
+			return false
+		}
+		if !routes[i].ScheduledDeparture.Equal(routes[j].ScheduledDeparture) {
+			return routes[i].ScheduledDeparture.Before(routes[j].ScheduledDeparture)
+		}
+		return routes[i].TravelMinutes < routes[j].TravelMinutes
+	})
+}
+
+func airportCodes(airports []models.Airport) []string {
+	codes := make([]string, len(airports))
+	for i, airport := range airports {
+		codes[i] = airport.AirportCode
+	}
+	return codes
+}
+
+func loadFlights(flightIDs []uint) (map[uint]models.Flight, error) {
+	flightsByID := make(map[uint]models.Flight, len(flightIDs))
+	if len(flightIDs) == 0 {
+		return flightsByID, nil
+	}
+
+	var flights []models.Flight
+	if err := db.Where("flight_id IN ?", flightIDs).Find(&flights).Error; err != nil {
+		return nil, err
+	}
+	for _, flight := range flights {
+		flightsByID[flight.FlightID] = flight
+	}
+	return flightsByID, nil
+}
+
+// newRoute собирает маршрут из упорядоченных перелётов, считая пересадки и общее время в пути.
+// Время вылета и прилёта переводится в часовой пояс соответствующего аэропорта.
+func newRoute(flights []models.Flight, zones map[string]*time.Location, prices map[uint]float64) models.Route {
+	first, last := flights[0], flights[len(flights)-1]
+	route := models.Route{
+		FlightIDs:          make([]uint, 0, len(flights)),
+		DepartureAirport:   first.DepartureAirport,
+		ArrivalAirport:     last.ArrivalAirport,
+		ScheduledDeparture: localTime(first.ScheduledDeparture, first.DepartureAirport, zones),
+		ScheduledArrival:   localTime(last.ScheduledArrival, last.ArrivalAirport, zones),
+		Stops:              len(flights) - 1,
+		TravelMinutes:      int(last.ScheduledArrival.Sub(first.ScheduledDeparture).Minutes()),
+		Legs:               make([]models.RouteLeg, 0, len(flights)),
+		Layovers:           make([]models.Layover, 0, len(flights)-1),
+	}
+
+	for i, flight := range flights {
+		route.FlightIDs = append(route.FlightIDs, flight.FlightID)
+		route.Legs = append(route.Legs, models.RouteLeg{
+			FlightID:           flight.FlightID,
+			FlightNo:           flight.FlightNo,
+			DepartureAirport:   flight.DepartureAirport,
+			ArrivalAirport:     flight.ArrivalAirport,
+			ScheduledDeparture: localTime(flight.ScheduledDeparture, flight.DepartureAirport, zones),
+			ScheduledArrival:   localTime(flight.ScheduledArrival, flight.ArrivalAirport, zones),
+			Price:              prices[flight.FlightID],
+		})
+		route.TotalPrice += prices[flight.FlightID]
+		if i > 0 {
+			prev := flights[i-1]
+			route.Layovers = append(route.Layovers, models.Layover{
+				Airport: flight.DepartureAirport,
+				Minutes: int(flight.ScheduledDeparture.Sub(prev.ScheduledArrival).Minutes()),
+			})
+		}
+	}
+	return route
+}
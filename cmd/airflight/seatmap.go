@@ -0,0 +1,99 @@
+package main
+
+import (
+	"time"
+
+	"github.com/AntonTsoy/airflight-service/internal/models"
+)
+
+// activeHolds считает места, удержанные по классам обслуживания на рейсе.
+func activeHolds(flightID uint, now time.Time) (map[string]int, error) {
+	var rows []struct {
+		FareConditions string
+		Seats          int
+	}
+	if err := db.Model(&models.SeatHold{}).
+		Select("fare_conditions, SUM(seats) AS seats").
+		Where("flight_id = ? AND status = ? AND expires_at > ?", flightID, models.HoldStatusActive, now).
+		Group("fare_conditions").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	held := make(map[string]int, len(rows))
+	for _, row := range rows {
+		held[row.FareConditions] = row.Seats
+	}
+	return held, nil
+}
+
+// buildSeatMap раскладывает места рейса по классам и рядам. Кресло занято, если на него
+// выдан посадочный талон, и удержано, если закреплено за активным удержанием
+// (assignHoldSeats). Проданные без регистрации места к креслам не привязаны и показываются
+// по классам количеством.
+func buildSeatMap(flight models.Flight) (models.SeatMap, error) {
+	seatMap := models.SeatMap{FlightID: flight.FlightID, AircraftCode: flight.AircraftCode, Cabins: []models.SeatMapCabin{}}
+
+	layout, fares, err := cabinLayout(db, flight.FlightID)
+	if err != nil {
+		return seatMap, err
+	}
+	occupied, err := occupiedSeats(db, flight.FlightID)
+	if err != nil {
+		return seatMap, err
+	}
+	now := time.Now()
+	heldSeatNos, err := heldSeats(db, flight.FlightID, now)
+	if err != nil {
+		return seatMap, err
+	}
+	held, err := activeHolds(flight.FlightID, now)
+	if err != nil {
+		return seatMap, err
+	}
+
+	cabins := make(map[string]int)
+	// Кресла без посадочного талона: свободные, удержанные и ждущие пассажиров с билетами.
+	vacant := make(map[string]int)
+	for _, seat := range layout {
+		fare := fares[seat.No]
+		if _, ok := cabins[fare]; !ok {
+			cabins[fare] = len(seatMap.Cabins)
+			seatMap.Cabins = append(seatMap.Cabins, models.SeatMapCabin{FareConditions: fare, Rows: []models.SeatMapRow{}})
+		}
+		status := models.SeatStatusFree
+		switch {
+		case occupied[seat.No]:
+			status = models.SeatStatusOccupied
+		case heldSeatNos[seat.No]:
+			status = models.SeatStatusHeld
+		}
+		if !occupied[seat.No] {
+			vacant[fare]++
+		}
+
+		cabin := &seatMap.Cabins[cabins[fare]]
+		if n := len(cabin.Rows); n == 0 || cabin.Rows[n-1].Row != seat.Row {
+			cabin.Rows = append(cabin.Rows, models.SeatMapRow{Row: seat.Row})
+		}
+		row := &cabin.Rows[len(cabin.Rows)-1]
+		row.Seats = append(row.Seats, models.SeatMapSeat{
+			SeatNo: seat.No,
+			Row:    seat.Row,
+			Letter: seat.Letter,
+			Kind:   string(seat.Kind),
+			Status: status,
+		})
+	}
+
+	for i := range seatMap.Cabins {
+		cabin := &seatMap.Cabins[i]
+		available, err := seatAvailability(db, []uint{flight.FlightID}, cabin.FareConditions, "")
+		if err != nil {
+			return seatMap, err
+		}
+		cabin.SeatsAvailable = available[flight.FlightID]
+		cabin.SeatsHeld = held[cabin.FareConditions]
+		cabin.SeatsUnassigned = max(0, vacant[cabin.FareConditions]-cabin.SeatsAvailable-cabin.SeatsHeld)
+	}
+	return seatMap, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AntonTsoy/airflight-service/internal/models"
+)
+
+var zoneCache struct {
+	sync.RWMutex
+	zones map[string]*time.Location
+}
+
+// airportZones возвращает часовые пояса всех аэропортов. Справочник загружается один раз:
+// аэропорты в демо-базе не меняются за время работы сервиса.
+func airportZones() (map[string]*time.Location, error) {
+	zoneCache.RLock()
+	zones := zoneCache.zones
+	zoneCache.RUnlock()
+	if zones != nil {
+		return zones, nil
+	}
+
+	var airports []models.Airport
+	if err := db.Select("airport_code", "timezone").Find(&airports).Error; err != nil {
+		return nil, err
+	}
+	zones = make(map[string]*time.Location, len(airports))
+	for _, airport := range airports {
+		loc, err := time.LoadLocation(airport.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q for airport %s: %v", airport.Timezone, airport.AirportCode, err)
+		}
+		zones[airport.AirportCode] = loc
+	}
+
+	zoneCache.Lock()
+	zoneCache.zones = zones
+	zoneCache.Unlock()
+	return zones, nil
+}
+
+func airportZone(code string) (*time.Location, error) {
+	zones, err := airportZones()
+	if err != nil {
+		return nil, err
+	}
+	if loc, ok := zones[code]; ok {
+		return loc, nil
+	}
+	return time.UTC, nil
+}
+
+func localTime(t time.Time, airportCode string, zones map[string]*time.Location) time.Time {
+	if loc, ok := zones[airportCode]; ok {
+		return t.In(loc)
+	}
+	return t.UTC()
+}
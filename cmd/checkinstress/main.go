@@ -0,0 +1,129 @@
+// checkinstress бронирует рейс на несколько пассажиров через API запущенного сервиса,
+// регистрирует их всех одновременно и проверяет, что места и номера посадочных
+// не повторяются. Завершается с кодом 1 при дубликатах или ошибках регистрации.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/AntonTsoy/airflight-service/internal/models"
+)
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8080", "service base URL")
+	flightID := flag.Uint("flight", 0, "flight to check in on; must be bookable")
+	fare := flag.String("fare", "Economy", "fare conditions of the bookings")
+	bookings := flag.Int("bookings", 50, "number of single-passenger bookings to check in concurrently")
+	flag.Parse()
+
+	if *flightID == 0 {
+		log.Fatal("-flight is required")
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	run := time.Now().UnixNano()
+
+	guids := make([]string, 0, *bookings)
+	for i := 0; i < *bookings; i++ {
+		guid := fmt.Sprintf("checkinstress-%d-%d", run, i)
+		req := models.BookingRequest{
+			Passengers:     []models.Passenger{{Name: fmt.Sprintf("STRESS PASSENGER %d", i)}},
+			FareConditions: *fare,
+			FlightIDs:      []uint{*flightID},
+		}
+		if status, body, err := call(client, http.MethodPut, *baseURL+"/bookings/"+guid, req); err != nil || status != http.StatusOK {
+			log.Fatalf("booking %d failed: status %d, %v: %s", i, status, err, body)
+		}
+		guids = append(guids, guid)
+	}
+	fmt.Printf("created %d bookings on flight %d\n", len(guids), *flightID)
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		passes []models.BoardingPass
+		failed int
+	)
+	start := make(chan struct{})
+	for _, guid := range guids {
+		wg.Add(1)
+		go func(guid string) {
+			defer wg.Done()
+			<-start
+			url := fmt.Sprintf("%s/bookings/%s/check-in/%d", *baseURL, guid, *flightID)
+			status, body, err := call(client, http.MethodPut, url, nil)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil || status != http.StatusOK {
+				log.Printf("check-in %s failed: status %d, %v: %s", guid, status, err, body)
+				failed++
+				return
+			}
+			var pass models.BoardingPass
+			if err := json.Unmarshal(body, &pass); err != nil {
+				log.Printf("check-in %s returned invalid JSON: %v", guid, err)
+				failed++
+				return
+			}
+			passes = append(passes, pass)
+		}(guid)
+	}
+	began := time.Now()
+	close(start)
+	wg.Wait()
+	fmt.Printf("%d check-ins in %s, %d failed\n", len(guids), time.Since(began).Round(time.Millisecond), failed)
+
+	seats := make(map[string]string, len(passes))
+	numbers := make(map[int]string, len(passes))
+	duplicates := 0
+	for _, pass := range passes {
+		if other, ok := seats[pass.SeatNo]; ok {
+			fmt.Printf("seat %s assigned to %s and %s\n", pass.SeatNo, other, pass.TicketNo)
+			duplicates++
+		}
+		if other, ok := numbers[pass.BoardingNo]; ok {
+			fmt.Printf("boarding number %d assigned to %s and %s\n", pass.BoardingNo, other, pass.TicketNo)
+			duplicates++
+		}
+		seats[pass.SeatNo] = pass.TicketNo
+		numbers[pass.BoardingNo] = pass.TicketNo
+	}
+
+	if duplicates > 0 || failed > 0 {
+		fmt.Printf("FAIL: %d duplicates, %d failed check-ins\n", duplicates, failed)
+		os.Exit(1)
+	}
+	fmt.Println("OK: all seats and boarding numbers are unique")
+}
+
+func call(client *http.Client, method, url string, payload interface{}) (int, []byte, error) {
+	var body io.Reader
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return 0, nil, err
+		}
+		body = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, raw, err
+}
@@ -0,0 +1,153 @@
+// routebench сравнивает поиск маршрутов рекурсивным SQL-запросом и индексом в памяти
+// на случайных парах аэропортов из базы, заданной в .env.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/AntonTsoy/airflight-service/internal/config"
+	"github.com/AntonTsoy/airflight-service/internal/models"
+	"github.com/AntonTsoy/airflight-service/internal/routing"
+)
+
+func main() {
+	pairs := flag.Int("pairs", 20, "number of random airport pairs")
+	runs := flag.Int("runs", 3, "runs per pair and finder")
+	connections := flag.Int("connections", 2, "maximum number of connections")
+	dateStr := flag.String("date", "", "departure date (YYYY-MM-DD); defaults to the middle of the schedule")
+	seed := flag.Int64("seed", 1, "random seed for pair selection")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := gorm.Open(postgres.Open(cfg.DatabaseDSN), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		log.Fatal("failed to connect to database:", err)
+	}
+
+	date, err := benchDate(db, *dateStr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	start := time.Now()
+	graph, err := routing.LoadGraph(db)
+	if err != nil {
+		log.Fatal("failed to load flight graph:", err)
+	}
+	fmt.Printf("graph loaded in %s\n", time.Since(start).Round(time.Millisecond))
+
+	var airports []models.Airport
+	if err := db.Select("airport_code").Order("airport_code").Find(&airports).Error; err != nil {
+		log.Fatal(err)
+	}
+	if len(airports) < 2 {
+		log.Fatal("not enough airports")
+	}
+
+	finders := []struct {
+		name   string
+		finder routing.Finder
+	}{
+		{"sql", routing.SQLFinder{DB: db}},
+		{"graph", graph},
+	}
+
+	rnd := rand.New(rand.NewSource(*seed))
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(out, "FROM\tTO\tPATHS\tSQL\tGRAPH\tMATCH")
+
+	totals := make([]time.Duration, len(finders))
+	mismatches := 0
+	for i := 0; i < *pairs; i++ {
+		from := airports[rnd.Intn(len(airports))].AirportCode
+		to := airports[rnd.Intn(len(airports))].AirportCode
+		if from == to {
+			i--
+			continue
+		}
+		q := routing.Query{
+			From:          []string{from},
+			To:            []string{to},
+			DateFrom:      date,
+			DateTo:        date,
+			MaxStops:      *connections,
+			MinConnection: cfg.MinConnectionTime,
+			MaxConnection: cfg.MaxConnectionTime,
+		}
+
+		results := make([][]string, len(finders))
+		timings := make([]time.Duration, len(finders))
+		for f, finder := range finders {
+			for r := 0; r < *runs; r++ {
+				start := time.Now()
+				paths, err := finder.finder.Paths(q)
+				if err != nil {
+					log.Fatalf("%s finder failed for %s-%s: %v", finder.name, from, to, err)
+				}
+				timings[f] += time.Since(start)
+				results[f] = normalize(paths)
+			}
+			timings[f] /= time.Duration(*runs)
+			totals[f] += timings[f]
+		}
+
+		match := slices.Equal(results[0], results[1])
+		if !match {
+			mismatches++
+		}
+		fmt.Fprintf(out, "%s\t%s\t%d\t%s\t%s\t%t\n", from, to, len(results[0]),
+			timings[0].Round(time.Microsecond), timings[1].Round(time.Microsecond), match)
+	}
+	out.Flush()
+
+	fmt.Printf("\ndate %s, connections %d, %d pairs x %d runs\n", date.Format(routing.DateLayout), *connections, *pairs, *runs)
+	for f, finder := range finders {
+		fmt.Printf("%-6s avg %s\n", finder.name, (totals[f] / time.Duration(*pairs)).Round(time.Microsecond))
+	}
+	if mismatches > 0 {
+		fmt.Printf("%d pairs returned different paths\n", mismatches)
+		os.Exit(1)
+	}
+}
+
+func benchDate(db *gorm.DB, dateStr string) (time.Time, error) {
+	if dateStr != "" {
+		return time.Parse(routing.DateLayout, dateStr)
+	}
+	var middle string
+	if err := db.Raw(`
+        SELECT to_char((MIN(scheduled_departure) + (MAX(scheduled_departure) - MIN(scheduled_departure)) / 2)::date, 'YYYY-MM-DD')
+        FROM flights`).Scan(&middle).Error; err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(routing.DateLayout, middle)
+}
+
+func normalize(paths [][]uint) []string {
+	keys := make([]string, len(paths))
+	for i, path := range paths {
+		ids := make([]string, len(path))
+		for j, id := range path {
+			ids[j] = fmt.Sprint(id)
+		}
+		keys[i] = strings.Join(ids, ",")
+	}
+	sort.Strings(keys)
+	return keys
+}
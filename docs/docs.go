@@ -34,16 +34,37 @@ const docTemplate = `{
                         "description": "Airport city",
                         "name": "city",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: airport_code (default), city or airport_name",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-500); default 50",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/main.Airport"
-                            }
+                            "$ref": "#/definitions/models.Page-models_Airport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid pagination parameters",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "500": {
@@ -75,20 +96,35 @@ const docTemplate = `{
                         "name": "airport_code",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: time (default), flight_no or airport",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-500); default 50",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/main.FlightSchedule"
-                            }
+                            "$ref": "#/definitions/models.Page-models_FlightSchedule"
                         }
                     },
                     "400": {
-                        "description": "Missing or invalid airport code",
+                        "description": "Missing or invalid airport code or pagination parameters",
                         "schema": {
                             "type": "string"
                         }
@@ -122,20 +158,35 @@ const docTemplate = `{
                         "name": "airport_code",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: time (default), flight_no or airport",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-500); default 50",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/main.FlightSchedule"
-                            }
+                            "$ref": "#/definitions/models.Page-models_FlightSchedule"
                         }
                     },
                     "400": {
-                        "description": "Missing or invalid airport code",
+                        "description": "Missing or invalid airport code or pagination parameters",
                         "schema": {
                             "type": "string"
                         }
@@ -152,75 +203,75 @@ const docTemplate = `{
                 }
             }
         },
-        "/bookings/{guid}": {
-            "put": {
-                "description": "Idempotent booking of flights with a GUID",
-                "consumes": [
-                    "application/json"
-                ],
+        "/boarding-passes/{ticket_no}": {
+            "get": {
+                "description": "Returns the boarding pass of a ticket with its IATA BCBP barcode data",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "bookings"
                 ],
-                "summary": "Book a route",
+                "summary": "Get a boarding pass",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "GUID",
-                        "name": "guid",
+                        "description": "Ticket number (13 digits)",
+                        "name": "ticket_no",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Booking data",
-                        "name": "booking",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main.BookingRequest"
-                        }
+                        "type": "integer",
+                        "description": "Flight ID; required when the ticket has boarding passes for several flights",
+                        "name": "flight_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Existing or new tickets",
+                        "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/main.TicketFlight"
-                            }
+                            "$ref": "#/definitions/models.BoardingPass"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid ticket number",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Boarding pass not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Passenger name or other data cannot be encoded in the barcode (bcbp_invalid)",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/bookings/{guid}/check-in/{flight_id}": {
-            "put": {
-                "description": "Assigns a seat for a booked flight using a GUID",
-                "consumes": [
-                    "application/json"
-                ],
+        "/bookings/{guid}": {
+            "get": {
+                "description": "Returns the booking for a GUID with flights, tickets and boarding passes",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "bookings"
                 ],
-                "summary": "Check-in for a flight",
+                "summary": "Get a booking",
                 "parameters": [
                     {
                         "type": "string",
@@ -228,46 +279,37 @@ const docTemplate = `{
                         "name": "guid",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Flight ID",
-                        "name": "flight_id",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Boarding pass details",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.BoardingPass"
+                            "$ref": "#/definitions/models.Booking"
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
+                        "description": "Missing guid",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Booking or seat not found",
+                        "description": "Booking not found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/cities": {
-            "get": {
-                "description": "Retrieve a list of all cities from the database",
+            },
+            "put": {
+                "description": "Idempotent booking of flights with a GUID for one or more passengers; one ticket per passenger per flight.\nAn active seat hold for the same GUID is confirmed and must match the requested flights and fare.\nThe total is charged through the payment provider before tickets are issued.",
                 "consumes": [
                     "application/json"
                 ],
@@ -275,173 +317,1320 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "cities"
+                    "bookings"
+                ],
+                "summary": "Book a route",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "GUID",
+                        "name": "guid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Booking data",
+                        "name": "booking",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BookingRequest"
+                        }
+                    }
                 ],
-                "summary": "Get all cities",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Existing or new tickets grouped by passenger",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "string"
+                                "$ref": "#/definitions/models.PassengerTickets"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "402": {
+                        "description": "Payment declined",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Flight not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "GUID already used for a different request, hold mismatch, fares changed, or a flight is cancelled, departed or sold out",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/routes": {
-            "get": {
-                "description": "Lists routes connecting two points (airport or city) with specified filters",
+            },
+            "delete": {
+                "description": "Cancels every active segment of the booking, releases the seats and refunds through the payment provider\n(never more than was charged; refund_pending is the part the provider could not process)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "routes"
+                    "bookings"
                 ],
-                "summary": "Get routes between two points",
+                "summary": "Cancel a booking",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Departure point (airport code or city)",
-                        "name": "from",
-                        "in": "query",
+                        "description": "Booking GUID",
+                        "name": "guid",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Arrival point (airport code or city)",
-                        "name": "to",
-                        "in": "query",
-                        "required": true
+                        "type": "boolean",
+                        "description": "Cancel even if a boarding pass was issued (the pass is voided)",
+                        "name": "force",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CancellationResult"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "Departure date (YYYY-MM-DD)",
-                        "name": "departure_date",
-                        "in": "query",
-                        "required": true
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Booking not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Boarding pass issued or flight departed",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
                     },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Moves every passenger of a segment to another flight between the same cities and/or to another fare class in one transaction.\nThe new flight must keep the minimum connection time to the neighbouring segments of the booking.\nInventory is re-checked, boarding passes of changed segments are voided and the fare difference\nis charged or refunded through the payment provider.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bookings"
+                ],
+                "summary": "Change booked flights or fare conditions",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Booking class (Economy, Comfort, Business)",
-                        "name": "booking_class",
-                        "in": "query",
+                        "description": "Booking GUID",
+                        "name": "guid",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Number of connections (0, 1, 2, 3); default 0",
-                        "name": "connections",
-                        "in": "query"
+                        "description": "Segment changes",
+                        "name": "changes",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BookingChangeRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of routes",
+                        "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/main.Route"
-                            }
+                            "$ref": "#/definitions/models.BookingChangeResult"
                         }
                     },
                     "400": {
                         "description": "Invalid input",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "402": {
+                        "description": "Payment of the fare difference declined",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Booking, segment or flight not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Flight departed, cancelled, sold out, already booked, on another route or out of itinerary order (route_mismatch), or fares changed (price_changed)",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "main.Airport": {
-            "type": "object",
-            "properties": {
-                "airport_code": {
-                    "type": "string"
-                },
-                "airport_name": {
-                    "type": "string"
-                },
-                "city": {
-                    "type": "string"
-                },
-                "timezone": {
+        },
+        "/bookings/{guid}/check-in/{flight_id}": {
+            "put": {
+                "description": "Assigns the requested seat or, without seat_no, the frontmost free seat of the fare class\n(window first, then aisle, unless seat_preference says otherwise)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bookings"
+                ],
+                "summary": "Check-in for a flight",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Booking GUID",
+                        "name": "guid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Flight ID",
+                        "name": "flight_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Passenger number; required when the booking has several passengers",
+                        "name": "passenger_no",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Requested seat or seat preference",
+                        "name": "seat",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.CheckInRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Boarding pass details with IATA BCBP barcode data",
+                        "schema": {
+                            "$ref": "#/definitions/models.BoardingPass"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input, or the seat is not on the aircraft or of another fare class",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Booking or seat not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Seat taken or held (seat_held), seat or boarding number conflict (checkin_conflict), already checked in to another seat, flight cancelled, or check-in not open (checkin_too_early) or closed (checkin_closed)",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Check-in kept conflicting with concurrent check-ins",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/bookings/{guid}/flights/{flight_id}": {
+            "delete": {
+                "description": "Cancels one segment of the booking, releases its seats and refunds through the payment provider",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bookings"
+                ],
+                "summary": "Cancel a booked flight",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Booking GUID",
+                        "name": "guid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Flight ID",
+                        "name": "flight_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Cancel even if a boarding pass was issued (the pass is voided)",
+                        "name": "force",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CancellationResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Booking not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Boarding pass issued or flight departed",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/bookings/{guid}/hold": {
+            "post": {
+                "description": "Reserves seats of a fare class on the given flights for the hold TTL; confirm with PUT /bookings/{guid}.\nHeld seats are assigned to concrete seat numbers and shown as held on the seat map.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bookings"
+                ],
+                "summary": "Hold seats before booking",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Booking GUID",
+                        "name": "guid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Flights, fare conditions and number of passengers",
+                        "name": "hold",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.HoldRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Existing or new hold",
+                        "schema": {
+                            "$ref": "#/definitions/models.Hold"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Flight not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Booking or different hold exists, or a flight is not bookable",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/cities": {
+            "get": {
+                "description": "Retrieve a list of all cities from the database",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cities"
+                ],
+                "summary": "Get all cities",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-500); default 50",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Page-string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid pagination parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/flights/{flight_id}/seat-map": {
+            "get": {
+                "description": "Lists every seat of the flight's aircraft grouped by fare conditions and row.\nEach seat is free, held (reserved by an active seat hold) or occupied (has a boarding pass).\nTickets sold without a boarding pass are not tied to a seat and are counted per cabin as seats_unassigned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "flights"
+                ],
+                "summary": "Get the seat map of a flight",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Flight ID",
+                        "name": "flight_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SeatMap"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Flight not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/routes": {
+            "get": {
+                "description": "Lists itineraries connecting two points (airport or city) with up to the given number of connections",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "routes"
+                ],
+                "summary": "Get routes between two points",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Departure point (airport code or city)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Arrival point (airport code or city)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Departure date (YYYY-MM-DD) in the local time of the departure airport",
+                        "name": "departure_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Booking class (Economy, Comfort, Business)",
+                        "name": "booking_class",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of connections (0, 1, 2, 3); default 0",
+                        "name": "connections",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum connection time in minutes; airport MCT still applies",
+                        "name": "min_connection",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum connection time in minutes",
+                        "name": "max_connection",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of passengers that must fit on every leg; default 1",
+                        "name": "passengers",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximum total price of the itinerary for the booking class",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: departure (default), arrival, duration, price or stops",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-500); default 50",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of itineraries with ordered legs and layovers",
+                        "schema": {
+                            "$ref": "#/definitions/models.Page-models_Route"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/routes/calendar": {
+            "get": {
+                "description": "For every day of the range returns whether any itinerary exists and its cheapest total fare",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "routes"
+                ],
+                "summary": "Get a fare calendar between two points",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Departure point (airport code or city)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Arrival point (airport code or city)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "First departure date (YYYY-MM-DD), local time of the departure airport",
+                        "name": "date_from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last departure date (YYYY-MM-DD), at most 31 days after date_from",
+                        "name": "date_to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Booking class (Economy, Comfort, Business)",
+                        "name": "booking_class",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of connections (0, 1, 2, 3); default 0",
+                        "name": "connections",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum connection time in minutes; airport MCT still applies",
+                        "name": "min_connection",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum connection time in minutes",
+                        "name": "max_connection",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of passengers that must fit on every leg; default 1",
+                        "name": "passengers",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "One entry per day of the range",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CalendarDay"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown departure or arrival point",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/routes/search": {
+            "post": {
+                "description": "Searches itineraries for every segment of a trip; return_date turns a single segment into a round trip.\nEvery segment returns its own page of itineraries; pass its next_cursor at the same index of cursors to get the next page.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "routes"
+                ],
+                "summary": "Search round-trip and multi-city routes",
+                "parameters": [
+                    {
+                        "description": "Trip segments and filters",
+                        "name": "search",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TripSearchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Itineraries per segment",
+                        "schema": {
+                            "$ref": "#/definitions/models.TripSearchResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown departure or arrival point",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.Airport": {
+            "type": "object",
+            "properties": {
+                "airport_code": {
+                    "type": "string"
+                },
+                "airport_name": {
+                    "type": "string"
+                },
+                "city": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.BoardingPass": {
+            "type": "object",
+            "properties": {
+                "bcbp": {
+                    "description": "BCBP — данные штрихкода IATA BCBP; не хранятся, а собираются при выдаче талона.",
+                    "type": "string"
+                },
+                "boarding_no": {
+                    "type": "integer"
+                },
+                "flight_id": {
+                    "type": "integer"
+                },
+                "seat_no": {
+                    "type": "string"
+                },
+                "ticket_no": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Booking": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BookingChange"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "guid": {
+                    "type": "string"
+                },
+                "passanger": {
+                    "type": "string"
+                },
+                "passengers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Passenger"
+                    }
+                },
+                "payment_id": {
+                    "type": "string"
+                },
+                "pnr": {
+                    "description": "PNR — короткий код бронирования для посадочных талонов.",
+                    "type": "string"
+                },
+                "segments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BookingSegmentDetails"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total_amount": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.BookingChange": {
+            "type": "object",
+            "properties": {
+                "change_id": {
+                    "type": "integer"
+                },
+                "changed_at": {
+                    "type": "string"
+                },
+                "fare_difference": {
+                    "type": "number"
+                },
+                "new_amount": {
+                    "type": "number"
+                },
+                "new_fare_conditions": {
+                    "type": "string"
+                },
+                "new_flight_id": {
+                    "type": "integer"
+                },
+                "old_amount": {
+                    "type": "number"
+                },
+                "old_fare_conditions": {
+                    "type": "string"
+                },
+                "old_flight_id": {
+                    "type": "integer"
+                },
+                "passengers": {
+                    "type": "integer"
+                },
+                "voided_boarding_passes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.BookingChangeRequest": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SegmentChange"
+                    }
+                }
+            }
+        },
+        "models.BookingChangeResult": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BookingChange"
+                    }
+                },
+                "guid": {
+                    "type": "string"
+                },
+                "payment_id": {
+                    "description": "Доплата списывается платежом PaymentID, разница в меньшую сторону возвращается.",
+                    "type": "string"
+                },
+                "refund_pending": {
+                    "type": "number"
+                },
+                "refunded": {
+                    "type": "number"
+                },
+                "total_amount": {
+                    "type": "number"
+                },
+                "total_difference": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.BookingRequest": {
+            "type": "object",
+            "properties": {
+                "fare_conditions": {
+                    "type": "string"
+                },
+                "flight_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "passanger": {
+                    "description": "Passanger — единственный пассажир в старом формате запроса; Passengers его заменяет.",
+                    "type": "string"
+                },
+                "passengers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Passenger"
+                    }
+                }
+            }
+        },
+        "models.BookingSegmentDetails": {
+            "type": "object",
+            "properties": {
+                "cancelled_at": {
+                    "type": "string"
+                },
+                "fare_conditions": {
+                    "type": "string"
+                },
+                "flight": {
+                    "$ref": "#/definitions/models.Flight"
+                },
+                "flight_id": {
+                    "type": "integer"
+                },
+                "refund_amount": {
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tickets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SegmentTicket"
+                    }
+                }
+            }
+        },
+        "models.CalendarDay": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "type": "boolean"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "min_price": {
+                    "type": "number"
+                },
+                "routes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CancellationResult": {
+            "type": "object",
+            "properties": {
+                "guid": {
+                    "type": "string"
+                },
+                "refund_pending": {
+                    "description": "RefundPending — часть возврата, которую провайдер не провёл (например, не знает платежа);\nона возвращается вручную.",
+                    "type": "number"
+                },
+                "segments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CancelledSegment"
+                    }
+                },
+                "total_refund": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CancelledSegment": {
+            "type": "object",
+            "properties": {
+                "amount_paid": {
+                    "type": "number"
+                },
+                "flight_id": {
+                    "type": "integer"
+                },
+                "refund_amount": {
+                    "type": "number"
+                },
+                "ticket_no": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CheckInRequest": {
+            "type": "object",
+            "properties": {
+                "seat_no": {
+                    "type": "string"
+                },
+                "seat_preference": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "flight_id": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Flight": {
+            "type": "object",
+            "properties": {
+                "aircraft_code": {
+                    "type": "string"
+                },
+                "arrival_airport": {
+                    "type": "string"
+                },
+                "departure_airport": {
+                    "type": "string"
+                },
+                "flight_id": {
+                    "type": "integer"
+                },
+                "flight_no": {
+                    "type": "string"
+                },
+                "scheduled_arrival": {
+                    "type": "string"
+                },
+                "scheduled_departure": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FlightSchedule": {
+            "type": "object",
+            "properties": {
+                "day_of_week": {
+                    "type": "string"
+                },
+                "flight_no": {
+                    "type": "string"
+                },
+                "origin_airport": {
+                    "type": "string"
+                },
+                "time_of_arrival": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "utc_offset": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Hold": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "fare_conditions": {
+                    "type": "string"
+                },
+                "flight_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "guid": {
+                    "type": "string"
+                },
+                "seats": {
+                    "type": "integer"
+                },
+                "status": {
                     "type": "string"
                 }
             }
         },
-        "main.BoardingPass": {
+        "models.HoldRequest": {
             "type": "object",
             "properties": {
-                "boarding_no": {
+                "fare_conditions": {
+                    "type": "string"
+                },
+                "flight_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "passengers": {
                     "type": "integer"
+                }
+            }
+        },
+        "models.Layover": {
+            "type": "object",
+            "properties": {
+                "airport": {
+                    "type": "string"
                 },
-                "flight_id": {
+                "minutes": {
                     "type": "integer"
+                }
+            }
+        },
+        "models.Page-models_Airport": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Airport"
+                    }
                 },
-                "seat_no": {
+                "next_cursor": {
                     "type": "string"
+                }
+            }
+        },
+        "models.Page-models_FlightSchedule": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FlightSchedule"
+                    }
                 },
-                "ticket_no": {
+                "next_cursor": {
                     "type": "string"
                 }
             }
         },
-        "main.BookingRequest": {
+        "models.Page-models_Route": {
             "type": "object",
             "properties": {
-                "fare_conditions": {
-                    "type": "string"
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Route"
+                    }
                 },
-                "flight_ids": {
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Page-string": {
+            "type": "object",
+            "properties": {
+                "items": {
                     "type": "array",
                     "items": {
-                        "type": "integer"
+                        "type": "string"
                     }
                 },
-                "passanger": {
+                "next_cursor": {
                     "type": "string"
                 }
             }
         },
-        "main.FlightSchedule": {
+        "models.Passenger": {
             "type": "object",
             "properties": {
-                "day_of_week": {
+                "contact": {
                     "type": "string"
                 },
-                "flight_no": {
+                "date_of_birth": {
                     "type": "string"
                 },
-                "origin_airport": {
+                "document_number": {
                     "type": "string"
                 },
-                "time_of_arrival": {
+                "name": {
                     "type": "string"
                 }
             }
         },
-        "main.Route": {
+        "models.PassengerTickets": {
+            "type": "object",
+            "properties": {
+                "passenger": {
+                    "$ref": "#/definitions/models.Passenger"
+                },
+                "passenger_no": {
+                    "type": "integer"
+                },
+                "tickets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TicketFlight"
+                    }
+                }
+            }
+        },
+        "models.Route": {
+            "type": "object",
+            "properties": {
+                "arrival_airport": {
+                    "type": "string"
+                },
+                "departure_airport": {
+                    "type": "string"
+                },
+                "fare_conditions": {
+                    "type": "string"
+                },
+                "flight_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "layovers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Layover"
+                    }
+                },
+                "legs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RouteLeg"
+                    }
+                },
+                "scheduled_arrival": {
+                    "type": "string"
+                },
+                "scheduled_departure": {
+                    "type": "string"
+                },
+                "stops": {
+                    "type": "integer"
+                },
+                "total_price": {
+                    "type": "number"
+                },
+                "travel_minutes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RouteLeg": {
             "type": "object",
             "properties": {
                 "arrival_airport": {
@@ -450,20 +1639,138 @@ const docTemplate = `{
                 "departure_airport": {
                     "type": "string"
                 },
+                "flight_id": {
+                    "type": "integer"
+                },
                 "flight_no": {
                     "type": "string"
                 },
+                "price": {
+                    "type": "number"
+                },
                 "scheduled_arrival": {
                     "type": "string"
                 },
                 "scheduled_departure": {
                     "type": "string"
+                },
+                "seats_available": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SeatMap": {
+            "type": "object",
+            "properties": {
+                "aircraft_code": {
+                    "type": "string"
+                },
+                "cabins": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SeatMapCabin"
+                    }
+                },
+                "flight_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SeatMapCabin": {
+            "type": "object",
+            "properties": {
+                "fare_conditions": {
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SeatMapRow"
+                    }
+                },
+                "seats_available": {
+                    "type": "integer"
+                },
+                "seats_held": {
+                    "type": "integer"
+                },
+                "seats_unassigned": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SeatMapRow": {
+            "type": "object",
+            "properties": {
+                "row": {
+                    "type": "integer"
+                },
+                "seats": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SeatMapSeat"
+                    }
+                }
+            }
+        },
+        "models.SeatMapSeat": {
+            "type": "object",
+            "properties": {
+                "kind": {
+                    "type": "string"
+                },
+                "letter": {
+                    "type": "string"
+                },
+                "row": {
+                    "type": "integer"
+                },
+                "seat_no": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SegmentChange": {
+            "type": "object",
+            "properties": {
+                "fare_conditions": {
+                    "type": "string"
+                },
+                "flight_id": {
+                    "type": "integer"
+                },
+                "new_flight_id": {
+                    "description": "NewFlightID и FareConditions необязательны: без них остаются прежний рейс или класс.",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SegmentTicket": {
+            "type": "object",
+            "properties": {
+                "boarding_pass": {
+                    "$ref": "#/definitions/models.BoardingPass"
+                },
+                "passenger_no": {
+                    "type": "integer"
+                },
+                "ticket": {
+                    "$ref": "#/definitions/models.TicketFlight"
+                },
+                "ticket_no": {
+                    "type": "string"
                 }
             }
         },
-        "main.TicketFlight": {
+        "models.TicketFlight": {
             "type": "object",
             "properties": {
+                "amount": {
+                    "type": "number"
+                },
                 "fare_conditions": {
                     "type": "string"
                 },
@@ -474,6 +1781,93 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "models.TripSearchRequest": {
+            "type": "object",
+            "properties": {
+                "booking_class": {
+                    "type": "string"
+                },
+                "connections": {
+                    "type": "integer"
+                },
+                "cursors": {
+                    "description": "Cursors — next_cursor из предыдущего ответа для каждого сегмента (вместе с обратным\nпри return_date); пустая строка или отсутствие курсора — первая страница сегмента.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "max_connection": {
+                    "type": "integer"
+                },
+                "max_price": {
+                    "type": "number"
+                },
+                "min_connection": {
+                    "type": "integer"
+                },
+                "passengers": {
+                    "type": "integer"
+                },
+                "return_date": {
+                    "type": "string"
+                },
+                "segments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TripSegment"
+                    }
+                },
+                "sort": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TripSearchResult": {
+            "type": "object",
+            "properties": {
+                "segments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TripSegmentResult"
+                    }
+                }
+            }
+        },
+        "models.TripSegment": {
+            "type": "object",
+            "properties": {
+                "departure_date": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TripSegmentResult": {
+            "type": "object",
+            "properties": {
+                "departure_date": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "routes": {
+                    "$ref": "#/definitions/models.Page-models_Route"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
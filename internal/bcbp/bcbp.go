@@ -0,0 +1,222 @@
+// Package bcbp кодирует и разбирает обязательные поля штрихкода посадочного талона
+// IATA BCBP (Resolution 792, формат M) для одного сегмента.
+package bcbp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Length — длина обязательной части для одного сегмента без условных полей.
+const Length = 60
+
+var ErrInvalid = errors.New("invalid BCBP data")
+
+const (
+	formatCode = 'M'
+	legs       = '1'
+	eTicket    = 'E'
+	// Поле размера условных и авиакомпанейских данных; они не кодируются.
+	noConditionalData = "00"
+
+	// Статус пассажира «зарегистрирован».
+	StatusCheckedIn = "1"
+)
+
+// Ширины полей в порядке их следования в строке.
+var fieldWidths = []int{1, 1, 20, 1, 7, 3, 3, 3, 5, 3, 1, 4, 5, 1, 2}
+
+type BoardingPass struct {
+	// PassengerName в формате IATA: "IVANOV/IVAN".
+	PassengerName string
+	ETicket       bool
+	PNR           string
+	From          string
+	To            string
+	Carrier       string
+	// FlightNumber — номер рейса без кода перевозчика: четыре цифры и необязательный суффикс.
+	FlightNumber string
+	// JulianDate — порядковый день года даты вылета (1–366).
+	JulianDate      int
+	Compartment     string
+	Seat            string
+	Sequence        int
+	PassengerStatus string
+}
+
+// Транслитерация кириллицы по ICAO Doc 9303 (как в загранпаспортах): "Щукин" → "SHCHUKIN".
+// Мягкий знак опускается.
+var cyrillic = map[rune]string{
+	'А': "A", 'Б': "B", 'В': "V", 'Г': "G", 'Д': "D", 'Е': "E", 'Ё': "E", 'Ж': "ZH",
+	'З': "Z", 'И': "I", 'Й': "I", 'К': "K", 'Л': "L", 'М': "M", 'Н': "N", 'О': "O",
+	'П': "P", 'Р': "R", 'С': "S", 'Т': "T", 'У': "U", 'Ф': "F", 'Х': "KH", 'Ц': "TS",
+	'Ч': "CH", 'Ш': "SH", 'Щ': "SHCH", 'Ъ': "IE", 'Ы': "Y", 'Ь': "", 'Э': "E", 'Ю': "IU",
+	'Я': "IA", 'Ґ': "G", 'Є': "IE", 'І': "I", 'Ї': "I", 'Ў': "U",
+}
+
+// Name переводит имя в формате демо-базы ("IVAN IVANOV") в формат BCBP ("IVANOV/IVAN"):
+// кириллица транслитерируется по ICAO 9303, из остального остаются латинские буквы,
+// пробелы и дефисы. Результат обрезается до ширины поля. Если букв не осталось, имя пустое,
+// и Encode его не примет.
+func Name(fullName string) string {
+	var clean strings.Builder
+	for _, r := range strings.ToUpper(fullName) {
+		if latin, ok := cyrillic[r]; ok {
+			clean.WriteString(latin)
+		} else if (r >= 'A' && r <= 'Z') || r == ' ' || r == '-' {
+			clean.WriteRune(r)
+		}
+	}
+	parts := strings.Fields(clean.String())
+	var name string
+	switch len(parts) {
+	case 0:
+		return ""
+	case 1:
+		name = parts[0]
+	default:
+		name = parts[len(parts)-1] + "/" + strings.Join(parts[:len(parts)-1], " ")
+	}
+	if len(name) > fieldWidths[2] {
+		name = name[:fieldWidths[2]]
+	}
+	return name
+}
+
+// Encode собирает строку BCBP. Номер рейса, место и порядковый номер приводятся
+// к виду с ведущими нулями, поэтому Parse возвращает их нормализованными. Штрихкод без
+// имени пассажира недействителен, поэтому пустое имя — ErrInvalid.
+func Encode(p BoardingPass) (string, error) {
+	if strings.TrimSpace(p.PassengerName) == "" {
+		return "", fmt.Errorf("%w: passenger name is empty", ErrInvalid)
+	}
+	flight, err := flightNumber(p.FlightNumber)
+	if err != nil {
+		return "", err
+	}
+	seat, err := seatNumber(p.Seat)
+	if err != nil {
+		return "", err
+	}
+	if p.JulianDate < 1 || p.JulianDate > 366 {
+		return "", fmt.Errorf("%w: julian date %d", ErrInvalid, p.JulianDate)
+	}
+	if p.Sequence < 0 || p.Sequence > 9999 {
+		return "", fmt.Errorf("%w: sequence number %d", ErrInvalid, p.Sequence)
+	}
+
+	ticket := " "
+	if p.ETicket {
+		ticket = string(eTicket)
+	}
+	values := []string{
+		string(formatCode),
+		string(legs),
+		p.PassengerName,
+		ticket,
+		p.PNR,
+		p.From,
+		p.To,
+		p.Carrier,
+		flight,
+		fmt.Sprintf("%03d", p.JulianDate),
+		p.Compartment,
+		seat,
+		fmt.Sprintf("%04d ", p.Sequence),
+		p.PassengerStatus,
+		noConditionalData,
+	}
+
+	var b strings.Builder
+	for i, value := range values {
+		if len(value) > fieldWidths[i] {
+			return "", fmt.Errorf("%w: field %d %q is longer than %d", ErrInvalid, i+1, value, fieldWidths[i])
+		}
+		if strings.ContainsFunc(value, func(r rune) bool { return r < ' ' || r > '~' }) {
+			return "", fmt.Errorf("%w: field %d %q is not printable ASCII", ErrInvalid, i+1, value)
+		}
+		b.WriteString(value)
+		b.WriteString(strings.Repeat(" ", fieldWidths[i]-len(value)))
+	}
+	return b.String(), nil
+}
+
+// Parse разбирает обязательные поля строки BCBP с одним сегментом. Условные поля,
+// если они есть, не разбираются.
+func Parse(data string) (BoardingPass, error) {
+	var p BoardingPass
+	if len(data) < Length {
+		return p, fmt.Errorf("%w: length %d, want at least %d", ErrInvalid, len(data), Length)
+	}
+	fields := make([]string, len(fieldWidths))
+	pos := 0
+	for i, width := range fieldWidths {
+		fields[i] = data[pos : pos+width]
+		pos += width
+	}
+	if fields[0][0] != formatCode {
+		return p, fmt.Errorf("%w: format code %q", ErrInvalid, fields[0])
+	}
+	if fields[1][0] != legs {
+		return p, fmt.Errorf("%w: only single-leg passes are supported, got %q legs", ErrInvalid, fields[1])
+	}
+
+	julian, err := strconv.Atoi(fields[9])
+	if err != nil || julian < 1 || julian > 366 {
+		return p, fmt.Errorf("%w: julian date %q", ErrInvalid, fields[9])
+	}
+	sequence, err := strconv.Atoi(strings.TrimSpace(fields[12]))
+	if err != nil {
+		return p, fmt.Errorf("%w: sequence number %q", ErrInvalid, fields[12])
+	}
+	seat := strings.TrimSpace(fields[11])
+	if seat != "" {
+		row, err := strconv.Atoi(seat[:len(seat)-1])
+		if err != nil {
+			return p, fmt.Errorf("%w: seat %q", ErrInvalid, fields[11])
+		}
+		seat = strconv.Itoa(row) + seat[len(seat)-1:]
+	}
+
+	p = BoardingPass{
+		PassengerName:   strings.TrimSpace(fields[2]),
+		ETicket:         fields[3][0] == eTicket,
+		PNR:             strings.TrimSpace(fields[4]),
+		From:            strings.TrimSpace(fields[5]),
+		To:              strings.TrimSpace(fields[6]),
+		Carrier:         strings.TrimSpace(fields[7]),
+		FlightNumber:    strings.TrimSpace(fields[8]),
+		JulianDate:      julian,
+		Compartment:     strings.TrimSpace(fields[10]),
+		Seat:            seat,
+		Sequence:        sequence,
+		PassengerStatus: strings.TrimSpace(fields[13]),
+	}
+	return p, nil
+}
+
+// flightNumber дополняет номер рейса нулями до четырёх цифр: "402" → "0402", "12A" → "0012A".
+func flightNumber(number string) (string, error) {
+	digits := strings.TrimRightFunc(number, func(r rune) bool { return r >= 'A' && r <= 'Z' })
+	suffix := number[len(digits):]
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 0 || n > 9999 || len(suffix) > 1 {
+		return "", fmt.Errorf("%w: flight number %q", ErrInvalid, number)
+	}
+	return fmt.Sprintf("%04d%s", n, suffix), nil
+}
+
+// seatNumber дополняет ряд нулями до трёх цифр: "1A" → "001A". Пустое место допустимо.
+func seatNumber(seat string) (string, error) {
+	if seat == "" {
+		return "", nil
+	}
+	row, err := strconv.Atoi(seat[:len(seat)-1])
+	letter := seat[len(seat)-1]
+	if err != nil || row < 0 || row > 999 || letter < 'A' || letter > 'Z' {
+		return "", fmt.Errorf("%w: seat %q", ErrInvalid, seat)
+	}
+	return fmt.Sprintf("%03d%c", row, letter), nil
+}
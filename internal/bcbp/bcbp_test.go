@@ -0,0 +1,208 @@
+package bcbp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func validPass() BoardingPass {
+	return BoardingPass{
+		PassengerName:   "TIKHONOV/VALERIY",
+		ETicket:         true,
+		PNR:             "K7M2QX",
+		From:            "DME",
+		To:              "LED",
+		Carrier:         "PG",
+		FlightNumber:    "0402",
+		JulianDate:      45,
+		Compartment:     "Y",
+		Seat:            "12A",
+		Sequence:        25,
+		PassengerStatus: StatusCheckedIn,
+	}
+}
+
+func TestEncode(t *testing.T) {
+	got, err := Encode(validPass())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "M1TIKHONOV/VALERIY    EK7M2QX DMELEDPG 0402 045Y012A0025 100"
+	if got != want {
+		t.Errorf("Encode() = %q, want %q", got, want)
+	}
+	if len(got) != Length {
+		t.Errorf("len(Encode()) = %d, want %d", len(got), Length)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(p *BoardingPass)
+		want func(p *BoardingPass)
+	}{
+		{name: "as is"},
+		{
+			name: "flight number is padded",
+			edit: func(p *BoardingPass) { p.FlightNumber = "402" },
+			want: func(p *BoardingPass) { p.FlightNumber = "0402" },
+		},
+		{
+			name: "flight number with suffix",
+			edit: func(p *BoardingPass) { p.FlightNumber = "12A" },
+			want: func(p *BoardingPass) { p.FlightNumber = "0012A" },
+		},
+		{
+			name: "seat row without leading zeros",
+			edit: func(p *BoardingPass) { p.Seat = "1C" },
+		},
+		{
+			name: "three-digit seat row",
+			edit: func(p *BoardingPass) { p.Seat = "123K" },
+		},
+		{
+			name: "no seat",
+			edit: func(p *BoardingPass) { p.Seat = "" },
+		},
+		{
+			name: "zero and large sequence numbers",
+			edit: func(p *BoardingPass) { p.Sequence = 0 },
+		},
+		{
+			name: "largest sequence number",
+			edit: func(p *BoardingPass) { p.Sequence = 9999 },
+		},
+		{
+			name: "paper ticket and last day of a leap year",
+			edit: func(p *BoardingPass) { p.ETicket = false; p.JulianDate = 366 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := validPass()
+			if tt.edit != nil {
+				tt.edit(&in)
+			}
+			want := in
+			if tt.want != nil {
+				tt.want(&want)
+			}
+
+			encoded, err := Encode(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(encoded) != Length {
+				t.Errorf("len(Encode()) = %d, want %d", len(encoded), Length)
+			}
+			got, err := Parse(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("Parse(Encode()) = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestEncodeRejectsInvalidFields(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(p *BoardingPass)
+	}{
+		{"julian date zero", func(p *BoardingPass) { p.JulianDate = 0 }},
+		{"julian date too large", func(p *BoardingPass) { p.JulianDate = 367 }},
+		{"negative sequence", func(p *BoardingPass) { p.Sequence = -1 }},
+		{"sequence too large", func(p *BoardingPass) { p.Sequence = 10000 }},
+		{"flight number too long", func(p *BoardingPass) { p.FlightNumber = "12345" }},
+		{"flight number not numeric", func(p *BoardingPass) { p.FlightNumber = "AB" }},
+		{"seat row too long", func(p *BoardingPass) { p.Seat = "1234A" }},
+		{"seat without letter", func(p *BoardingPass) { p.Seat = "12" }},
+		{"passenger name too long", func(p *BoardingPass) { p.PassengerName = strings.Repeat("A", 21) }},
+		{"passenger name empty", func(p *BoardingPass) { p.PassengerName = "" }},
+		{"passenger name blank", func(p *BoardingPass) { p.PassengerName = "   " }},
+		{"PNR too long", func(p *BoardingPass) { p.PNR = "ABCDEFGH" }},
+		{"airport code too long", func(p *BoardingPass) { p.From = "DMEE" }},
+		{"carrier too long", func(p *BoardingPass) { p.Carrier = "PGXX" }},
+		{"non-ASCII name", func(p *BoardingPass) { p.PassengerName = "ТИХОНОВ/В" }},
+		{"control character", func(p *BoardingPass) { p.PNR = "AB\tCD" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := validPass()
+			tt.edit(&p)
+			if _, err := Encode(p); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Encode() error = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalidData(t *testing.T) {
+	valid, err := Encode(validPass())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"too short", valid[:Length-1]},
+		{"bad format code", "S" + valid[1:]},
+		{"several legs", valid[:1] + "2" + valid[2:]},
+		{"julian date not numeric", valid[:44] + "ABC" + valid[47:]},
+		{"julian date zero", valid[:44] + "000" + valid[47:]},
+		{"julian date too large", valid[:44] + "367" + valid[47:]},
+		{"sequence not numeric", valid[:52] + "00X5 " + valid[57:]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.data); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Parse(%q) error = %v, want ErrInvalid", tt.data, err)
+			}
+		})
+	}
+}
+
+func TestParseIgnoresConditionalData(t *testing.T) {
+	valid, err := Encode(validPass())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Parse(valid + ">5180")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != validPass() {
+		t.Errorf("Parse() = %+v, want %+v", got, validPass())
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Valeriy Tikhonov", "TIKHONOV/VALERIY"},
+		{"ANNA MARIA IVANOVA", "IVANOVA/ANNA MARIA"},
+		{"Madonna", "MADONNA"},
+		{"  Ivan   Petrov-Vodkin ", "PETROV-VODKIN/IVAN"},
+		{"Jean-Luc O'Neil", "ONEIL/JEAN-LUC"},
+		{"Иван Иванов", "IVANOV/IVAN"},
+		{"Юлия Щербакова", "SHCHERBAKOVA/IULIIA"},
+		{"Пётр Подъячев", "PODIEIACHEV/PETR"},
+		{"Олег Ильин-Хрусталёв", "ILIN-KHRUSTALEV/OLEG"},
+		{"王伟", ""},
+		{"", ""},
+		{"Aleksandr Konstantinopolskiy", "KONSTANTINOPOLSKIY/A"},
+	}
+	for _, tt := range tests {
+		if got := Name(tt.in); got != tt.want {
+			t.Errorf("Name(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -3,13 +3,29 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	ListenAddr  string
-	DatabaseDSN string
+	ListenAddr        string
+	DatabaseDSN       string
+	MinConnectionTime time.Duration
+	MaxConnectionTime time.Duration
+	// Период проверки расписания для индекса маршрутов; 0 отключает индекс.
+	RouteIndexRefresh time.Duration
+	// Трёхзначный префикс перевозчика в номерах выписываемых билетов.
+	TicketPrefix string
+	// Сколько удерживаются места до подтверждения бронирования и как часто снимаются просроченные
+	// удержания; HoldSweepInterval 0 отключает сборщик (места всё равно освобождаются по expires_at).
+	HoldTTL           time.Duration
+	HoldSweepInterval time.Duration
+	// Платёжный провайдер бронирований; пока доступен только "fake" — имитация в памяти.
+	PaymentProvider string
+	// Регистрация открывается за CheckInOpens и закрывается за CheckInCloses до планового вылета.
+	CheckInOpens  time.Duration
+	CheckInCloses time.Duration
 }
 
 func Load() (*Config, error) {
@@ -19,10 +35,23 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
-	return &Config{
-		ListenAddr:  getString("LISTEN_ADDR"),
-		DatabaseDSN: getString("DATABASE_DSN"),
-	}, nil
+	cfg := &Config{
+		ListenAddr:        getString("LISTEN_ADDR"),
+		DatabaseDSN:       getString("DATABASE_DSN"),
+		MinConnectionTime: getDuration("MIN_CONNECTION_TIME", 45*time.Minute),
+		MaxConnectionTime: getDuration("MAX_CONNECTION_TIME", 24*time.Hour),
+		RouteIndexRefresh: getDuration("ROUTE_INDEX_REFRESH", time.Minute),
+		TicketPrefix:      getStringOr("TICKET_PREFIX", "999"),
+		HoldTTL:           getDuration("HOLD_TTL", 15*time.Minute),
+		HoldSweepInterval: getDuration("HOLD_SWEEP_INTERVAL", time.Minute),
+		PaymentProvider:   getStringOr("PAYMENT_PROVIDER", "fake"),
+		CheckInOpens:      getDuration("CHECKIN_OPENS", 24*time.Hour),
+		CheckInCloses:     getDuration("CHECKIN_CLOSES", 40*time.Minute),
+	}
+	if cfg.HoldTTL <= 0 {
+		return nil, fmt.Errorf("HOLD_TTL must be positive, got %s", cfg.HoldTTL)
+	}
+	return cfg, nil
 }
 
 func getString(key string) (value string) {
@@ -32,3 +61,23 @@ func getString(key string) (value string) {
 	}
 	return value
 }
+
+func getStringOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		fmt.Printf("invalid duration in environment variable %s: %q, using %s\n", key, raw, fallback)
+		return fallback
+	}
+	return value
+}
@@ -6,3 +6,8 @@ type Airport struct {
 	City        string `json:"city"`
 	Timezone    string `json:"timezone"`
 }
+
+type AirportConnectionTime struct {
+	AirportCode          string `gorm:"column:airport_code;primaryKey" json:"airport_code"`
+	MinConnectionMinutes int    `gorm:"column:min_connection_minutes" json:"min_connection_minutes"`
+}
@@ -5,4 +5,13 @@ type BoardingPass struct {
 	FlightID   uint   `gorm:"column:flight_id;primaryKey" json:"flight_id"`
 	BoardingNo int    `gorm:"column:boarding_no" json:"boarding_no"`
 	SeatNo     string `gorm:"column:seat_no" json:"seat_no"`
+	// BCBP — данные штрихкода IATA BCBP; не хранятся, а собираются при выдаче талона.
+	BCBP string `gorm:"-" json:"bcbp,omitempty"`
+}
+
+// CheckInRequest — необязательное тело запроса регистрации. Без seat_no место
+// назначается автоматически с учётом seat_preference (window или aisle).
+type CheckInRequest struct {
+	SeatNo         string `json:"seat_no,omitempty"`
+	SeatPreference string `json:"seat_preference,omitempty"`
 }
@@ -1,15 +1,197 @@
 package models
 
+import (
+	"time"
+)
+
+const (
+	BookStatusActive    = "active"
+	BookStatusCancelled = "cancelled"
+	// Бронирование проходит pending → paid → ticketed; билеты выписываются только после списания
+	// оплаты. Старые бронирования без оплаты остаются в статусе active.
+	BookStatusPending  = "pending"
+	BookStatusPaid     = "paid"
+	BookStatusTicketed = "ticketed"
+	// Сегмент заменён другим рейсом через PATCH /bookings/{guid}.
+	BookStatusChanged = "changed"
+
+	HoldStatusActive    = "active"
+	HoldStatusConfirmed = "confirmed"
+	HoldStatusExpired   = "expired"
+)
+
+type BookingHeader struct {
+	GUID        string    `gorm:"column:guid;primaryKey" json:"guid"`
+	Passanger   string    `gorm:"column:passanger" json:"passanger"`
+	Status      string    `gorm:"column:status;default:active" json:"status"`
+	TotalAmount float64   `gorm:"column:total_amount" json:"total_amount"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+	// Отпечаток исходного BookingRequest для проверки повторов PUT /bookings/{guid}.
+	RequestFingerprint string `gorm:"column:request_fingerprint" json:"-"`
+	PaymentID          string `gorm:"column:payment_id" json:"payment_id,omitempty"`
+	// PNR — короткий код бронирования для посадочных талонов.
+	PNR string `gorm:"column:pnr" json:"pnr"`
+}
+
+type BookingSegment struct {
+	GUID           string     `gorm:"column:guid;primaryKey" json:"-"`
+	FlightID       uint       `gorm:"column:flight_id;primaryKey" json:"flight_id"`
+	FareConditions string     `gorm:"column:fare_conditions" json:"fare_conditions"`
+	Status         string     `gorm:"column:status;default:active" json:"status"`
+	CancelledAt    *time.Time `gorm:"column:cancelled_at" json:"cancelled_at,omitempty"`
+	RefundAmount   *float64   `gorm:"column:refund_amount" json:"refund_amount,omitempty"`
+}
+
+type BookingPassenger struct {
+	GUID           string     `gorm:"column:guid;primaryKey"`
+	PassengerNo    int        `gorm:"column:passenger_no;primaryKey"`
+	Name           string     `gorm:"column:name"`
+	DocumentNumber string     `gorm:"column:document_number"`
+	DateOfBirth    *time.Time `gorm:"column:date_of_birth;type:date"`
+	Contact        string     `gorm:"column:contact"`
+}
+
+// Book связывает билет с сегментом бронирования и пассажиром.
 type Book struct {
-	GUID           string `gorm:"column:guid;primaryKey" json:"guid"`
-	FlightID       uint   `gorm:"column:flight_id" json:"flight_id"`
-	FareConditions string `gorm:"column:fare_conditions" json:"fare_conditions"`
-	TicketNo       string `gorm:"column:ticket_no" json:"ticket_no"`
-	Passanger      string `gorm:"column:passanger" json:"passanger"`
+	TicketNo    string `gorm:"column:ticket_no;primaryKey" json:"ticket_no"`
+	GUID        string `gorm:"column:guid" json:"guid"`
+	FlightID    uint   `gorm:"column:flight_id" json:"flight_id"`
+	PassengerNo int    `gorm:"column:passenger_no" json:"passenger_no"`
+}
+
+type Passenger struct {
+	Name           string `json:"name"`
+	DocumentNumber string `json:"document_number,omitempty"`
+	DateOfBirth    string `json:"date_of_birth,omitempty"`
+	Contact        string `json:"contact,omitempty"`
 }
 
 type BookingRequest struct {
-	Passanger      string `json:"passanger"`
+	// Passanger — единственный пассажир в старом формате запроса; Passengers его заменяет.
+	Passanger      string      `json:"passanger,omitempty"`
+	Passengers     []Passenger `json:"passengers,omitempty"`
+	FareConditions string      `json:"fare_conditions"`
+	FlightIDs      []uint      `json:"flight_ids"`
+}
+
+type PassengerTickets struct {
+	PassengerNo int            `json:"passenger_no"`
+	Passenger   Passenger      `json:"passenger"`
+	Tickets     []TicketFlight `json:"tickets"`
+}
+
+type SegmentTicket struct {
+	PassengerNo  int           `json:"passenger_no"`
+	TicketNo     string        `json:"ticket_no"`
+	Ticket       *TicketFlight `json:"ticket"`
+	BoardingPass *BoardingPass `json:"boarding_pass"`
+}
+
+type BookingSegmentDetails struct {
+	BookingSegment
+	Flight  Flight          `json:"flight"`
+	Tickets []SegmentTicket `json:"tickets"`
+}
+
+type Booking struct {
+	BookingHeader
+	Passengers []Passenger             `json:"passengers"`
+	Segments   []BookingSegmentDetails `json:"segments"`
+	Changes    []BookingChange         `json:"changes"`
+}
+
+type CancelledSegment struct {
+	FlightID     uint    `json:"flight_id"`
+	TicketNo     string  `json:"ticket_no"`
+	AmountPaid   float64 `json:"amount_paid"`
+	RefundAmount float64 `json:"refund_amount"`
+}
+
+type CancellationResult struct {
+	GUID        string             `json:"guid"`
+	Segments    []CancelledSegment `json:"segments"`
+	TotalRefund float64            `json:"total_refund"`
+	// RefundPending — часть возврата, которую провайдер не провёл (например, не знает платежа);
+	// она возвращается вручную.
+	RefundPending float64 `json:"refund_pending,omitempty"`
+}
+
+// BookingPayment — списание по бронированию: оплата при создании или доплата при изменении.
+type BookingPayment struct {
+	PaymentID     string    `gorm:"column:payment_id;primaryKey" json:"payment_id"`
+	GUID          string    `gorm:"column:guid" json:"-"`
+	Amount        float64   `gorm:"column:amount" json:"amount"`
+	Refunded      float64   `gorm:"column:refunded" json:"refunded"`
+	RefundPending float64   `gorm:"column:refund_pending" json:"refund_pending"`
+	CreatedAt     time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+type SeatHold struct {
+	GUID           string    `gorm:"column:guid;primaryKey" json:"-"`
+	FlightID       uint      `gorm:"column:flight_id;primaryKey" json:"flight_id"`
+	FareConditions string    `gorm:"column:fare_conditions" json:"fare_conditions"`
+	Seats          int       `gorm:"column:seats" json:"seats"`
+	Status         string    `gorm:"column:status" json:"status"`
+	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
+	ExpiresAt      time.Time `gorm:"column:expires_at" json:"expires_at"`
+}
+
+// SeatHoldSeat — кресло, закреплённое за удержанием на рейсе.
+type SeatHoldSeat struct {
+	GUID     string `gorm:"column:guid;primaryKey"`
+	FlightID uint   `gorm:"column:flight_id;primaryKey"`
+	SeatNo   string `gorm:"column:seat_no;primaryKey"`
+}
+
+type HoldRequest struct {
 	FareConditions string `json:"fare_conditions"`
 	FlightIDs      []uint `json:"flight_ids"`
+	Passengers     int    `json:"passengers"`
+}
+
+type Hold struct {
+	GUID           string    `json:"guid"`
+	FareConditions string    `json:"fare_conditions"`
+	Seats          int       `json:"seats"`
+	FlightIDs      []uint    `json:"flight_ids"`
+	Status         string    `json:"status"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// BookingChange — запись истории изменений сегмента бронирования.
+type BookingChange struct {
+	ID                uint      `gorm:"column:change_id;primaryKey" json:"change_id"`
+	GUID              string    `gorm:"column:guid" json:"-"`
+	OldFlightID       uint      `gorm:"column:old_flight_id" json:"old_flight_id"`
+	NewFlightID       uint      `gorm:"column:new_flight_id" json:"new_flight_id"`
+	OldFareConditions string    `gorm:"column:old_fare_conditions" json:"old_fare_conditions"`
+	NewFareConditions string    `gorm:"column:new_fare_conditions" json:"new_fare_conditions"`
+	Passengers        int       `gorm:"column:passengers" json:"passengers"`
+	OldAmount         float64   `gorm:"column:old_amount" json:"old_amount"`
+	NewAmount         float64   `gorm:"column:new_amount" json:"new_amount"`
+	FareDifference    float64   `gorm:"column:fare_difference" json:"fare_difference"`
+	VoidedPasses      int       `gorm:"column:voided_boarding_passes" json:"voided_boarding_passes"`
+	ChangedAt         time.Time `gorm:"column:changed_at" json:"changed_at"`
+}
+
+type SegmentChange struct {
+	FlightID uint `json:"flight_id"`
+	// NewFlightID и FareConditions необязательны: без них остаются прежний рейс или класс.
+	NewFlightID    uint   `json:"new_flight_id,omitempty"`
+	FareConditions string `json:"fare_conditions,omitempty"`
+}
+
+type BookingChangeRequest struct {
+	Changes []SegmentChange `json:"changes"`
+}
+
+type BookingChangeResult struct {
+	GUID            string          `json:"guid"`
+	Changes         []BookingChange `json:"changes"`
+	TotalDifference float64         `json:"total_difference"`
+	TotalAmount     float64         `json:"total_amount"`
+	// Доплата списывается платежом PaymentID, разница в меньшую сторону возвращается.
+	PaymentID     string  `json:"payment_id,omitempty"`
+	Refunded      float64 `json:"refunded,omitempty"`
+	RefundPending float64 `json:"refund_pending,omitempty"`
 }
@@ -0,0 +1,8 @@
+package models
+
+type ErrorResponse struct {
+	Error    string   `json:"error"`
+	Message  string   `json:"message"`
+	FlightID *uint    `json:"flight_id,omitempty"`
+	Details  []string `json:"details,omitempty"`
+}
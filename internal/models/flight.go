@@ -4,6 +4,15 @@ import (
 	"time"
 )
 
+const (
+	FlightStatusScheduled = "Scheduled"
+	FlightStatusOnTime    = "On Time"
+	FlightStatusDelayed   = "Delayed"
+	FlightStatusDeparted  = "Departed"
+	FlightStatusArrived   = "Arrived"
+	FlightStatusCancelled = "Cancelled"
+)
+
 type Flight struct {
 	FlightID           uint      `json:"flight_id" gorm:"column:flight_id;primaryKey"`
 	FlightNo           string    `json:"flight_no" gorm:"column:flight_no"`
@@ -11,17 +20,22 @@ type Flight struct {
 	ScheduledDeparture time.Time `json:"scheduled_departure" gorm:"column:scheduled_departure"`
 	ArrivalAirport     string    `json:"arrival_airport" gorm:"column:arrival_airport"`
 	DepartureAirport   string    `json:"departure_airport" gorm:"column:departure_airport"`
+	AircraftCode       string    `json:"aircraft_code" gorm:"column:aircraft_code"`
+	Status             string    `json:"status" gorm:"column:status"`
 }
 
 type FlightSchedule struct {
 	DayOfWeek     string `json:"day_of_week"`
 	TimeOfArrival string `json:"time_of_arrival"`
+	UTCOffset     string `json:"utc_offset"`
+	Timezone      string `json:"timezone"`
 	FlightNo      string `json:"flight_no"`
 	OriginAirport string `json:"origin_airport"`
 }
 
 type TicketFlight struct {
-	TicketNo       string `gorm:"column:ticket_no;primaryKey" json:"ticket_no"`
-	FlightID       uint   `gorm:"column:flight_id" json:"flight_id"`
-	FareConditions string `gorm:"column:fare_conditions" json:"fare_conditions"`
+	TicketNo       string  `gorm:"column:ticket_no;primaryKey" json:"ticket_no"`
+	FlightID       uint    `gorm:"column:flight_id" json:"flight_id"`
+	FareConditions string  `gorm:"column:fare_conditions" json:"fare_conditions"`
+	Amount         float64 `gorm:"column:amount" json:"amount"`
 }
@@ -4,10 +4,72 @@ import (
 	"time"
 )
 
-type Route struct {
+type RouteLeg struct {
+	FlightID           uint      `json:"flight_id"`
 	FlightNo           string    `json:"flight_no"`
 	DepartureAirport   string    `json:"departure_airport"`
 	ArrivalAirport     string    `json:"arrival_airport"`
 	ScheduledDeparture time.Time `json:"scheduled_departure"`
 	ScheduledArrival   time.Time `json:"scheduled_arrival"`
+	Price              float64   `json:"price"`
+	SeatsAvailable     int       `json:"seats_available"`
+}
+
+type Layover struct {
+	Airport string `json:"airport"`
+	Minutes int    `json:"minutes"`
+}
+
+type Route struct {
+	FlightIDs          []uint     `json:"flight_ids"`
+	DepartureAirport   string     `json:"departure_airport"`
+	ArrivalAirport     string     `json:"arrival_airport"`
+	ScheduledDeparture time.Time  `json:"scheduled_departure"`
+	ScheduledArrival   time.Time  `json:"scheduled_arrival"`
+	Stops              int        `json:"stops"`
+	TravelMinutes      int        `json:"travel_minutes"`
+	FareConditions     string     `json:"fare_conditions"`
+	TotalPrice         float64    `json:"total_price"`
+	Legs               []RouteLeg `json:"legs"`
+	Layovers           []Layover  `json:"layovers"`
+}
+
+type TripSegment struct {
+	From          string `json:"from"`
+	To            string `json:"to"`
+	DepartureDate string `json:"departure_date"`
+}
+
+type TripSearchRequest struct {
+	Segments      []TripSegment `json:"segments"`
+	ReturnDate    string        `json:"return_date,omitempty"`
+	BookingClass  string        `json:"booking_class"`
+	Connections   int           `json:"connections"`
+	Passengers    int           `json:"passengers"`
+	MinConnection *int          `json:"min_connection,omitempty"`
+	MaxConnection *int          `json:"max_connection,omitempty"`
+	MaxPrice      float64       `json:"max_price,omitempty"`
+	Sort          string        `json:"sort,omitempty"`
+	Limit         int           `json:"limit,omitempty"`
+	// Cursors — next_cursor из предыдущего ответа для каждого сегмента (вместе с обратным
+	// при return_date); пустая строка или отсутствие курсора — первая страница сегмента.
+	Cursors []string `json:"cursors,omitempty"`
+}
+
+type TripSegmentResult struct {
+	From          string      `json:"from"`
+	To            string      `json:"to"`
+	DepartureDate string      `json:"departure_date"`
+	Routes        Page[Route] `json:"routes"`
+}
+
+type TripSearchResult struct {
+	Segments []TripSegmentResult `json:"segments"`
+}
+
+type CalendarDay struct {
+	Date      string   `json:"date"`
+	Available bool     `json:"available"`
+	MinPrice  *float64 `json:"min_price"`
+	Routes    int      `json:"routes"`
 }
@@ -5,3 +5,39 @@ type Seat struct {
 	SeatNo         string `gorm:"column:seat_no;primaryKey" json:"seat_no"`
 	FareConditions string `gorm:"column:fare_conditions" json:"fare_conditions"`
 }
+
+const (
+	SeatStatusFree     = "free"
+	SeatStatusHeld     = "held"
+	SeatStatusOccupied = "occupied"
+)
+
+type SeatMapSeat struct {
+	SeatNo string `json:"seat_no"`
+	Row    int    `json:"row"`
+	Letter string `json:"letter"`
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+}
+
+type SeatMapRow struct {
+	Row   int           `json:"row"`
+	Seats []SeatMapSeat `json:"seats"`
+}
+
+// SeatMapCabin — места одного класса. Удержанные кресла помечены на схеме статусом held;
+// проданные без регистрации места к креслам не привязаны и показываются количеством
+// SeatsUnassigned. Из свободных кресел для продажи доступны SeatsAvailable.
+type SeatMapCabin struct {
+	FareConditions  string       `json:"fare_conditions"`
+	SeatsAvailable  int          `json:"seats_available"`
+	SeatsHeld       int          `json:"seats_held"`
+	SeatsUnassigned int          `json:"seats_unassigned"`
+	Rows            []SeatMapRow `json:"rows"`
+}
+
+type SeatMap struct {
+	FlightID     uint           `json:"flight_id"`
+	AircraftCode string         `json:"aircraft_code"`
+	Cabins       []SeatMapCabin `json:"cabins"`
+}
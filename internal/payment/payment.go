@@ -0,0 +1,137 @@
+// Package payment описывает платёжного провайдера бронирований и его имитацию
+// для локальной разработки.
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+var (
+	ErrDeclined     = errors.New("payment declined")
+	ErrNotFound     = errors.New("payment not found")
+	ErrInvalidState = errors.New("invalid payment state")
+)
+
+// PaymentProvider списывает оплату в два шага: авторизация суммы и её списание (capture).
+// Несписанную авторизацию снимает Void. Возврат возможен только в пределах списанной суммы.
+type PaymentProvider interface {
+	// Authorize блокирует amount на счёте плательщика; reference — GUID бронирования.
+	Authorize(reference string, amount float64) (paymentID string, err error)
+	Capture(paymentID string, amount float64) error
+	Void(paymentID string) error
+	Refund(paymentID string, amount float64) error
+}
+
+const (
+	StatusAuthorized = "authorized"
+	StatusCaptured   = "captured"
+	StatusVoided     = "voided"
+	StatusRefunded   = "refunded"
+)
+
+type Payment struct {
+	ID         string
+	Reference  string
+	Authorized float64
+	Captured   float64
+	Refunded   float64
+	Status     string
+}
+
+// Fake хранит платежи в памяти. Авторизации сверх DeclineOver отклоняются
+// (0 — без ограничения), что позволяет проверять сценарий отказа. После перезапуска
+// сервиса прежние платежи ему неизвестны: Refund и Void возвращают ErrNotFound.
+type Fake struct {
+	DeclineOver float64
+
+	mu       sync.Mutex
+	next     uint64
+	payments map[string]*Payment
+}
+
+func NewFake() *Fake {
+	return &Fake{payments: make(map[string]*Payment)}
+}
+
+func (f *Fake) Authorize(reference string, amount float64) (string, error) {
+	if amount <= 0 {
+		return "", fmt.Errorf("%w: amount must be positive", ErrInvalidState)
+	}
+	if f.DeclineOver > 0 && amount > f.DeclineOver {
+		return "", ErrDeclined
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.next++
+	id := fmt.Sprintf("fake-%d", f.next)
+	f.payments[id] = &Payment{ID: id, Reference: reference, Authorized: amount, Status: StatusAuthorized}
+	return id, nil
+}
+
+func (f *Fake) Capture(paymentID string, amount float64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.payments[paymentID]
+	if !ok {
+		return ErrNotFound
+	}
+	if p.Status != StatusAuthorized {
+		return fmt.Errorf("%w: payment %s is %s", ErrInvalidState, paymentID, p.Status)
+	}
+	if amount > p.Authorized {
+		return fmt.Errorf("%w: capture %.2f exceeds authorized %.2f", ErrInvalidState, amount, p.Authorized)
+	}
+	p.Captured = amount
+	p.Status = StatusCaptured
+	return nil
+}
+
+func (f *Fake) Void(paymentID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.payments[paymentID]
+	if !ok {
+		return ErrNotFound
+	}
+	if p.Status != StatusAuthorized {
+		return fmt.Errorf("%w: payment %s is %s", ErrInvalidState, paymentID, p.Status)
+	}
+	p.Status = StatusVoided
+	return nil
+}
+
+func (f *Fake) Refund(paymentID string, amount float64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.payments[paymentID]
+	if !ok {
+		return ErrNotFound
+	}
+	if p.Status == StatusAuthorized || p.Status == StatusVoided {
+		return fmt.Errorf("%w: payment %s is not captured", ErrInvalidState, paymentID)
+	}
+	// Сравнение в копейках, чтобы сумма частичных возвратов не упиралась в погрешность float.
+	if math.Round((p.Refunded+amount)*100) > math.Round(p.Captured*100) {
+		return fmt.Errorf("%w: refund %.2f exceeds the remaining %.2f", ErrInvalidState, amount, p.Captured-p.Refunded)
+	}
+	p.Refunded += amount
+	if math.Round(p.Refunded*100) == math.Round(p.Captured*100) {
+		p.Status = StatusRefunded
+	}
+	return nil
+}
+
+// Get возвращает копию платежа, например для отладки.
+func (f *Fake) Get(paymentID string) (Payment, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.payments[paymentID]
+	if !ok {
+		return Payment{}, false
+	}
+	return *p, true
+}
@@ -0,0 +1,125 @@
+package payment
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFakeDeclineOver(t *testing.T) {
+	f := NewFake()
+	f.DeclineOver = 1000
+
+	if _, err := f.Authorize("guid-1", 1000); err != nil {
+		t.Errorf("Authorize(1000) error = %v, want nil", err)
+	}
+	if _, err := f.Authorize("guid-2", 1000.01); !errors.Is(err, ErrDeclined) {
+		t.Errorf("Authorize(1000.01) error = %v, want ErrDeclined", err)
+	}
+	if _, err := f.Authorize("guid-3", 0); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Authorize(0) error = %v, want ErrInvalidState", err)
+	}
+
+	f.DeclineOver = 0
+	if _, err := f.Authorize("guid-4", 1_000_000); err != nil {
+		t.Errorf("Authorize without a limit error = %v, want nil", err)
+	}
+}
+
+func TestFakeCapture(t *testing.T) {
+	f := NewFake()
+	id, err := f.Authorize("guid-1", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Capture(id, 100.01); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Capture over the authorized amount error = %v, want ErrInvalidState", err)
+	}
+	if err := f.Capture("fake-unknown", 10); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Capture of an unknown payment error = %v, want ErrNotFound", err)
+	}
+	if err := f.Capture(id, 80); err != nil {
+		t.Fatalf("Capture(80) error = %v", err)
+	}
+	if err := f.Capture(id, 20); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("second Capture error = %v, want ErrInvalidState", err)
+	}
+	if err := f.Void(id); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Void of a captured payment error = %v, want ErrInvalidState", err)
+	}
+
+	p, _ := f.Get(id)
+	if p.Status != StatusCaptured || p.Captured != 80 {
+		t.Errorf("payment = %+v, want captured 80", p)
+	}
+}
+
+func TestFakeVoid(t *testing.T) {
+	f := NewFake()
+	id, err := f.Authorize("guid-1", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Void(id); err != nil {
+		t.Fatalf("Void() error = %v", err)
+	}
+	if err := f.Capture(id, 100); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Capture after Void error = %v, want ErrInvalidState", err)
+	}
+	if err := f.Refund(id, 10); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Refund after Void error = %v, want ErrInvalidState", err)
+	}
+	if err := f.Void("fake-unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Void of an unknown payment error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFakeRefund(t *testing.T) {
+	f := NewFake()
+	id, err := f.Authorize("guid-1", 0.3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Refund(id, 0.1); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Refund before Capture error = %v, want ErrInvalidState", err)
+	}
+	if err := f.Capture(id, 0.3); err != nil {
+		t.Fatal(err)
+	}
+
+	// 0.1 + 0.2 во float64 больше 0.3, но в копейках это ровно списанная сумма.
+	if err := f.Refund(id, 0.1); err != nil {
+		t.Fatalf("Refund(0.1) error = %v", err)
+	}
+	if err := f.Refund(id, 0.2); err != nil {
+		t.Fatalf("Refund(0.2) error = %v", err)
+	}
+	p, _ := f.Get(id)
+	if p.Status != StatusRefunded {
+		t.Errorf("status = %s, want %s", p.Status, StatusRefunded)
+	}
+	if err := f.Refund(id, 0.01); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Refund over the captured amount error = %v, want ErrInvalidState", err)
+	}
+	if err := f.Refund("fake-unknown", 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Refund of an unknown payment error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFakePartialRefund(t *testing.T) {
+	f := NewFake()
+	id, _ := f.Authorize("guid-1", 100)
+	if err := f.Capture(id, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Refund(id, 60); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Refund(id, 40.01); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Refund over the remaining amount error = %v, want ErrInvalidState", err)
+	}
+	p, _ := f.Get(id)
+	if p.Status != StatusCaptured || p.Refunded != 60 {
+		t.Errorf("payment = %+v, want captured with 60 refunded", p)
+	}
+}
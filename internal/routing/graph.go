@@ -0,0 +1,130 @@
+package routing
+
+import (
+	"sort"
+	"time"
+
+	"github.com/AntonTsoy/airflight-service/internal/models"
+)
+
+type edge struct {
+	id        uint
+	to        string
+	departure time.Time
+	arrival   time.Time
+}
+
+// Graph — расписание, развёрнутое во времени: для каждого аэропорта вылеты отсортированы
+// по времени, поэтому подходящие стыковки находятся бинарным поиском.
+type Graph struct {
+	departures map[string][]edge
+	zones      map[string]*time.Location
+	mct        map[string]time.Duration
+}
+
+func NewGraph(flights []models.Flight, zones map[string]*time.Location, mct map[string]time.Duration) *Graph {
+	g := &Graph{
+		departures: make(map[string][]edge),
+		zones:      zones,
+		mct:        mct,
+	}
+	for _, f := range flights {
+		g.departures[f.DepartureAirport] = append(g.departures[f.DepartureAirport], edge{
+			id:        f.FlightID,
+			to:        f.ArrivalAirport,
+			departure: f.ScheduledDeparture,
+			arrival:   f.ScheduledArrival,
+		})
+	}
+	for _, edges := range g.departures {
+		sort.Slice(edges, func(i, j int) bool {
+			if !edges[i].departure.Equal(edges[j].departure) {
+				return edges[i].departure.Before(edges[j].departure)
+			}
+			return edges[i].id < edges[j].id
+		})
+	}
+	return g
+}
+
+// Paths повторяет семантику SQLFinder: те же окна вылета, MCT и запрет повторных аэропортов.
+func (g *Graph) Paths(q Query) ([][]uint, error) {
+	s := search{
+		g:       g,
+		q:       q,
+		from:    toSet(q.From),
+		to:      toSet(q.To),
+		visited: make(map[string]bool),
+	}
+
+	for _, origin := range q.From {
+		loc := g.zones[origin]
+		if loc == nil {
+			loc = time.UTC
+		}
+		start := time.Date(q.DateFrom.Year(), q.DateFrom.Month(), q.DateFrom.Day(), 0, 0, 0, 0, loc)
+		end := time.Date(q.DateTo.Year(), q.DateTo.Month(), q.DateTo.Day()+1, 0, 0, 0, 0, loc)
+
+		edges := g.departures[origin]
+		i := sort.Search(len(edges), func(i int) bool { return !edges[i].departure.Before(start) })
+		for ; i < len(edges) && edges[i].departure.Before(end); i++ {
+			e := edges[i]
+			if s.from[e.to] {
+				continue
+			}
+			s.visited[origin] = true
+			s.walk(e)
+			s.visited[origin] = false
+		}
+	}
+	return s.paths, nil
+}
+
+type search struct {
+	g       *Graph
+	q       Query
+	from    map[string]bool
+	to      map[string]bool
+	visited map[string]bool
+	path    []uint
+	paths   [][]uint
+}
+
+func (s *search) walk(e edge) {
+	s.path = append(s.path, e.id)
+	s.visited[e.to] = true
+	defer func() {
+		s.path = s.path[:len(s.path)-1]
+		s.visited[e.to] = false
+	}()
+
+	if s.to[e.to] {
+		s.paths = append(s.paths, append([]uint(nil), s.path...))
+		return
+	}
+	if len(s.path) > s.q.MaxStops {
+		return
+	}
+
+	minConnection := max(s.q.MinConnection.Truncate(time.Minute), s.g.mct[e.to])
+	earliest := e.arrival.Add(minConnection)
+	latest := e.arrival.Add(s.q.MaxConnection.Truncate(time.Minute))
+
+	edges := s.g.departures[e.to]
+	i := sort.Search(len(edges), func(i int) bool { return !edges[i].departure.Before(earliest) })
+	for ; i < len(edges) && !edges[i].departure.After(latest); i++ {
+		next := edges[i]
+		if s.from[next.to] || s.visited[next.to] {
+			continue
+		}
+		s.walk(next)
+	}
+}
+
+func toSet(codes []string) map[string]bool {
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		set[code] = true
+	}
+	return set
+}
@@ -0,0 +1,254 @@
+package routing
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/AntonTsoy/airflight-service/internal/models"
+)
+
+var testDay = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func flight(id uint, from, to, departure string, minutes int) models.Flight {
+	at, err := time.ParseInLocation("15:04", departure, time.UTC)
+	if err != nil {
+		panic(err)
+	}
+	dep := testDay.Add(time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute)
+	return models.Flight{
+		FlightID:           id,
+		DepartureAirport:   from,
+		ArrivalAirport:     to,
+		ScheduledDeparture: dep,
+		ScheduledArrival:   dep.Add(time.Duration(minutes) * time.Minute),
+	}
+}
+
+func query(from, to string, maxStops int) Query {
+	return Query{
+		From:          []string{from},
+		To:            []string{to},
+		DateFrom:      testDay,
+		DateTo:        testDay,
+		MaxStops:      maxStops,
+		MinConnection: 0,
+		MaxConnection: 24 * time.Hour,
+	}
+}
+
+func sortedPaths(t *testing.T, g *Graph, q Query) [][]uint {
+	t.Helper()
+	paths, err := g.Paths(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.SortFunc(paths, slices.Compare[[]uint])
+	return paths
+}
+
+func TestGraphPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		flights []models.Flight
+		mct     map[string]time.Duration
+		query   func(q Query) Query
+		from    string
+		to      string
+		stops   int
+		want    [][]uint
+	}{
+		{
+			name:    "direct flight",
+			flights: []models.Flight{flight(1, "AAA", "BBB", "10:00", 60)},
+			from:    "AAA", to: "BBB", stops: 0,
+			want: [][]uint{{1}},
+		},
+		{
+			name: "departure outside the date window",
+			flights: []models.Flight{
+				flight(1, "AAA", "BBB", "10:00", 60),
+				{FlightID: 2, DepartureAirport: "AAA", ArrivalAirport: "BBB",
+					ScheduledDeparture: testDay.Add(26 * time.Hour), ScheduledArrival: testDay.Add(27 * time.Hour)},
+			},
+			from: "AAA", to: "BBB", stops: 0,
+			want: [][]uint{{1}},
+		},
+		{
+			name: "query minimum connection time",
+			flights: []models.Flight{
+				flight(1, "AAA", "BBB", "10:00", 60),
+				flight(2, "BBB", "CCC", "11:30", 60),
+				flight(3, "BBB", "CCC", "12:30", 60),
+			},
+			query: func(q Query) Query { q.MinConnection = 45 * time.Minute; return q },
+			from:  "AAA", to: "CCC", stops: 1,
+			want: [][]uint{{1, 3}},
+		},
+		{
+			name: "airport MCT overrides a shorter minimum connection",
+			flights: []models.Flight{
+				flight(1, "AAA", "BBB", "10:00", 60),
+				flight(2, "BBB", "CCC", "11:30", 60),
+				flight(3, "BBB", "CCC", "12:30", 60),
+			},
+			mct:   map[string]time.Duration{"BBB": 90 * time.Minute},
+			query: func(q Query) Query { q.MinConnection = 20 * time.Minute; return q },
+			from:  "AAA", to: "CCC", stops: 1,
+			want: [][]uint{{1, 3}},
+		},
+		{
+			name: "connection exactly at MCT is allowed",
+			flights: []models.Flight{
+				flight(1, "AAA", "BBB", "10:00", 60),
+				flight(2, "BBB", "CCC", "11:30", 60),
+			},
+			mct:  map[string]time.Duration{"BBB": 30 * time.Minute},
+			from: "AAA", to: "CCC", stops: 1,
+			want: [][]uint{{1, 2}},
+		},
+		{
+			name: "maximum connection time",
+			flights: []models.Flight{
+				flight(1, "AAA", "BBB", "10:00", 60),
+				flight(2, "BBB", "CCC", "11:30", 60),
+				flight(3, "BBB", "CCC", "13:30", 60),
+			},
+			query: func(q Query) Query { q.MaxConnection = 2 * time.Hour; return q },
+			from:  "AAA", to: "CCC", stops: 1,
+			want: [][]uint{{1, 2}},
+		},
+		{
+			name: "airports are not revisited",
+			flights: []models.Flight{
+				flight(1, "AAA", "BBB", "08:00", 60),
+				flight(2, "BBB", "CCC", "10:00", 60),
+				flight(3, "CCC", "BBB", "12:00", 60),
+				flight(4, "BBB", "DDD", "14:00", 60),
+				flight(5, "BBB", "AAA", "10:30", 60),
+				flight(6, "AAA", "DDD", "12:00", 60),
+			},
+			from: "AAA", to: "DDD", stops: 3,
+			want: [][]uint{{1, 4}, {6}},
+		},
+		{
+			name: "path stops at the destination",
+			flights: []models.Flight{
+				flight(1, "AAA", "BBB", "08:00", 60),
+				flight(2, "BBB", "CCC", "10:00", 60),
+			},
+			from: "AAA", to: "BBB", stops: 2,
+			want: [][]uint{{1}},
+		},
+		{
+			name: "MaxStops limits the depth",
+			flights: []models.Flight{
+				flight(1, "AAA", "BBB", "06:00", 60),
+				flight(2, "BBB", "CCC", "08:00", 60),
+				flight(3, "CCC", "DDD", "10:00", 60),
+			},
+			from: "AAA", to: "DDD", stops: 1,
+			want: [][]uint{},
+		},
+		{
+			name: "MaxStops allows enough connections",
+			flights: []models.Flight{
+				flight(1, "AAA", "BBB", "06:00", 60),
+				flight(2, "BBB", "CCC", "08:00", 60),
+				flight(3, "CCC", "DDD", "10:00", 60),
+			},
+			from: "AAA", to: "DDD", stops: 2,
+			want: [][]uint{{1, 2, 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGraph(tt.flights, map[string]*time.Location{}, tt.mct)
+			q := query(tt.from, tt.to, tt.stops)
+			if tt.query != nil {
+				q = tt.query(q)
+			}
+			got := sortedPaths(t, g, q)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Paths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraphPathsUsesOriginTimezone(t *testing.T) {
+	vladivostok := time.FixedZone("UTC+10", 10*60*60)
+	// 23:00 UTC 28 февраля — уже 1 марта во Владивостоке.
+	f := models.Flight{
+		FlightID:           1,
+		DepartureAirport:   "VVO",
+		ArrivalAirport:     "KHV",
+		ScheduledDeparture: testDay.Add(-time.Hour),
+		ScheduledArrival:   testDay,
+	}
+	g := NewGraph([]models.Flight{f}, map[string]*time.Location{"VVO": vladivostok}, nil)
+
+	got := sortedPaths(t, g, query("VVO", "KHV", 0))
+	if want := [][]uint{{1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Paths() = %v, want %v", got, want)
+	}
+}
+
+// benchmarkGraph строит сеть из airports аэропортов с flightsPerDay рейсами в день на трое суток.
+func benchmarkGraph(airports, flightsPerDay int) (*Graph, []string) {
+	rnd := rand.New(rand.NewSource(1))
+	codes := make([]string, airports)
+	for i := range codes {
+		codes[i] = fmt.Sprintf("A%02d", i)
+	}
+
+	var flights []models.Flight
+	for day := 0; day < 3; day++ {
+		for i := 0; i < flightsPerDay; i++ {
+			from := codes[rnd.Intn(airports)]
+			to := codes[rnd.Intn(airports)]
+			if from == to {
+				continue
+			}
+			dep := testDay.AddDate(0, 0, day).Add(time.Duration(rnd.Intn(24*60)) * time.Minute)
+			flights = append(flights, models.Flight{
+				FlightID:           uint(len(flights) + 1),
+				DepartureAirport:   from,
+				ArrivalAirport:     to,
+				ScheduledDeparture: dep,
+				ScheduledArrival:   dep.Add(time.Duration(60+rnd.Intn(240)) * time.Minute),
+			})
+		}
+	}
+	return NewGraph(flights, map[string]*time.Location{}, nil), codes
+}
+
+func BenchmarkGraphPaths(b *testing.B) {
+	g, codes := benchmarkGraph(100, 600)
+	q := Query{
+		DateFrom:      testDay,
+		DateTo:        testDay,
+		MaxStops:      2,
+		MinConnection: 45 * time.Minute,
+		MaxConnection: 24 * time.Hour,
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q.From = []string{codes[i%len(codes)]}
+		q.To = []string{codes[(i*7+3)%len(codes)]}
+		if q.From[0] == q.To[0] {
+			continue
+		}
+		if _, err := g.Paths(q); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
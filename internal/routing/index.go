@@ -0,0 +1,138 @@
+package routing
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/AntonTsoy/airflight-service/internal/models"
+)
+
+// Index держит Graph в памяти и перестраивает его, когда меняется таблица flights
+// или минимальные времена стыковки.
+type Index struct {
+	db *gorm.DB
+
+	mu          sync.RWMutex
+	graph       *Graph
+	fingerprint string
+	loadedAt    time.Time
+}
+
+func NewIndex(db *gorm.DB) *Index {
+	return &Index{db: db}
+}
+
+func (idx *Index) Ready() bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.graph != nil
+}
+
+func (idx *Index) Paths(q Query) ([][]uint, error) {
+	idx.mu.RLock()
+	g := idx.graph
+	idx.mu.RUnlock()
+	if g == nil {
+		return nil, fmt.Errorf("flight index is not loaded")
+	}
+	return g.Paths(q)
+}
+
+// Refresh перестраивает граф, только если изменился отпечаток расписания.
+func (idx *Index) Refresh() error {
+	fingerprint, err := idx.currentFingerprint()
+	if err != nil {
+		return err
+	}
+	idx.mu.RLock()
+	unchanged := idx.graph != nil && fingerprint == idx.fingerprint
+	idx.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	g, err := LoadGraph(idx.db)
+	if err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	idx.graph = g
+	idx.fingerprint = fingerprint
+	idx.loadedAt = time.Now()
+	idx.mu.Unlock()
+	return nil
+}
+
+// Run опрашивает базу каждые interval до закрытия stop.
+func (idx *Index) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := idx.Refresh(); err != nil {
+				log.Println("failed to refresh flight index:", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (idx *Index) currentFingerprint() (string, error) {
+	var row struct {
+		Flights  int64
+		MaxID    int64
+		Schedule string
+		MCT      string
+	}
+	err := idx.db.Raw(`
+        SELECT COUNT(*) AS flights,
+               COALESCE(MAX(flight_id), 0) AS max_id,
+               COALESCE(SUM(EXTRACT(EPOCH FROM scheduled_departure) + EXTRACT(EPOCH FROM scheduled_arrival)), 0)::text AS schedule,
+               (SELECT COALESCE(string_agg(airport_code || ':' || min_connection_minutes, ',' ORDER BY airport_code), '')
+                FROM airport_connection_times) AS mct
+        FROM flights`).Scan(&row).Error
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d/%d/%s/%s", row.Flights, row.MaxID, row.Schedule, row.MCT), nil
+}
+
+// LoadGraph читает расписание, часовые пояса аэропортов и MCT целиком.
+func LoadGraph(db *gorm.DB) (*Graph, error) {
+	var flights []models.Flight
+	if err := db.Select("flight_id", "departure_airport", "arrival_airport", "scheduled_departure", "scheduled_arrival").
+		Find(&flights).Error; err != nil {
+		return nil, err
+	}
+
+	var airports []models.Airport
+	if err := db.Select("airport_code", "timezone").Find(&airports).Error; err != nil {
+		return nil, err
+	}
+	zones := make(map[string]*time.Location, len(airports))
+	for _, airport := range airports {
+		loc, err := time.LoadLocation(airport.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q for airport %s: %v", airport.Timezone, airport.AirportCode, err)
+		}
+		zones[airport.AirportCode] = loc
+	}
+
+	var connectionTimes []models.AirportConnectionTime
+	if err := db.Find(&connectionTimes).Error; err != nil {
+		return nil, err
+	}
+	mct := make(map[string]time.Duration, len(connectionTimes))
+	for _, ct := range connectionTimes {
+		mct[ct.AirportCode] = time.Duration(ct.MinConnectionMinutes) * time.Minute
+	}
+
+	return NewGraph(flights, zones, mct), nil
+}
@@ -0,0 +1,24 @@
+package routing
+
+import (
+	"time"
+)
+
+const DateLayout = "2006-01-02"
+
+// Query описывает поиск цепочек рейсов. DateFrom и DateTo — календарные даты вылета
+// (включительно) по местному времени аэропорта отправления.
+type Query struct {
+	From          []string
+	To            []string
+	DateFrom      time.Time
+	DateTo        time.Time
+	MaxStops      int
+	MinConnection time.Duration
+	MaxConnection time.Duration
+}
+
+// Finder возвращает цепочки flight_id, ведущие из Query.From в Query.To.
+type Finder interface {
+	Paths(q Query) ([][]uint, error)
+}
@@ -0,0 +1,80 @@
+package routing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SQLFinder обходит граф рейсов рекурсивным запросом: каждая строка paths — цепочка
+// рейсов из From, продлеваемая, пока не достигнут To или лимит пересадок.
+// Повторный заход в уже посещённый аэропорт запрещён, поэтому циклов нет.
+type SQLFinder struct {
+	DB *gorm.DB
+}
+
+func (f SQLFinder) Paths(q Query) ([][]uint, error) {
+	var rows []struct{ FlightIDs string }
+	err := f.DB.Raw(`
+        WITH RECURSIVE paths AS (
+            SELECT ARRAY[f.flight_id] AS flight_ids,
+                   ARRAY[f.departure_airport, f.arrival_airport]::text[] AS airports,
+                   f.arrival_airport AS last_airport,
+                   f.scheduled_arrival AS last_arrival,
+                   0 AS stops
+            FROM flights f
+            JOIN airports a ON a.airport_code = f.departure_airport
+            WHERE f.departure_airport IN @from
+            AND f.scheduled_departure >= (@date_from::date)::timestamp AT TIME ZONE a.timezone
+            AND f.scheduled_departure < (@date_to::date + 1)::timestamp AT TIME ZONE a.timezone
+            AND f.arrival_airport NOT IN @from
+          UNION ALL
+            SELECT p.flight_ids || f.flight_id,
+                   p.airports || f.arrival_airport::text,
+                   f.arrival_airport,
+                   f.scheduled_arrival,
+                   p.stops + 1
+            FROM paths p
+            JOIN flights f ON f.departure_airport = p.last_airport
+            LEFT JOIN airport_connection_times mct ON mct.airport_code = p.last_airport
+            WHERE p.stops < @max_stops
+            AND p.last_airport NOT IN @to
+            AND f.scheduled_departure >= p.last_arrival
+                + make_interval(mins => GREATEST(@min_minutes, COALESCE(mct.min_connection_minutes, 0)))
+            AND f.scheduled_departure <= p.last_arrival + make_interval(mins => @max_minutes)
+            AND f.arrival_airport NOT IN @from
+            AND NOT (f.arrival_airport::text = ANY(p.airports))
+        )
+        SELECT array_to_string(flight_ids, ',') AS flight_ids
+        FROM paths
+        WHERE last_airport IN @to`,
+		map[string]interface{}{
+			"from":        q.From,
+			"to":          q.To,
+			"date_from":   q.DateFrom.Format(DateLayout),
+			"date_to":     q.DateTo.Format(DateLayout),
+			"max_stops":   q.MaxStops,
+			"min_minutes": int(q.MinConnection.Minutes()),
+			"max_minutes": int(q.MaxConnection.Minutes()),
+		}).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([][]uint, 0, len(rows))
+	for _, row := range rows {
+		parts := strings.Split(row.FlightIDs, ",")
+		path := make([]uint, 0, len(parts))
+		for _, part := range parts {
+			id, err := strconv.ParseUint(part, 10, 0)
+			if err != nil {
+				return nil, fmt.Errorf("invalid flight path %q: %v", row.FlightIDs, err)
+			}
+			path = append(path, uint(id))
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,178 @@
+// Package seats разбирает номера мест демо-базы ("12A": ряд и буква) и выбирает место
+// при регистрации.
+package seats
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalid = errors.New("invalid seat number")
+
+type Kind string
+
+const (
+	Window Kind = "window"
+	Aisle  Kind = "aisle"
+	Middle Kind = "middle"
+)
+
+type Seat struct {
+	No     string
+	Row    int
+	Letter string
+	Kind   Kind
+}
+
+// Parse делит номер места на ряд и букву: "12A" → 12, "A".
+func Parse(seatNo string) (int, string, error) {
+	if len(seatNo) < 2 {
+		return 0, "", fmt.Errorf("%w: %q", ErrInvalid, seatNo)
+	}
+	letter := seatNo[len(seatNo)-1]
+	if letter < 'A' || letter > 'Z' {
+		return 0, "", fmt.Errorf("%w: %q", ErrInvalid, seatNo)
+	}
+	row, err := strconv.Atoi(seatNo[:len(seatNo)-1])
+	if err != nil || row < 1 {
+		return 0, "", fmt.Errorf("%w: %q", ErrInvalid, seatNo)
+	}
+	return row, string(letter), nil
+}
+
+// Блоки кресел между проходами в зависимости от числа кресел в ряду. В схемах мест
+// демо-базы нет проходов, поэтому компоновка восстанавливается по ширине ряда.
+var rowBlocks = map[int][]int{
+	1:  {1},
+	2:  {1, 1},
+	3:  {1, 2},
+	4:  {2, 2},
+	5:  {2, 3},
+	6:  {3, 3},
+	7:  {2, 3, 2},
+	8:  {2, 4, 2},
+	9:  {3, 3, 3},
+	10: {3, 4, 3},
+}
+
+// Буквы мест по порядку; I не используется, чтобы не путать её с 1.
+const seatLetters = "ABCDEFGHJKLMNOPQRSTUVWXYZ"
+
+// Layout разбирает все места салона и определяет тип каждого: у окна, у прохода или
+// посередине. Места упорядочены от носа к хвосту и слева направо.
+//
+// Тип определяется по ширине ряда (rowBlocks). Ряд с пропущенными буквами (например,
+// A C D F или ряд без одного кресла) размечается по самому широкому полному ряду салона,
+// содержащему все его буквы: пропуск означает снятое кресло, а не более узкий салон.
+func Layout(seatNos []string) ([]Seat, error) {
+	result := make([]Seat, 0, len(seatNos))
+	for _, no := range seatNos {
+		row, letter, err := Parse(no)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, Seat{No: no, Row: row, Letter: letter})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Row != result[j].Row {
+			return result[i].Row < result[j].Row
+		}
+		return result[i].Letter < result[j].Letter
+	})
+
+	rows := make(map[int]string)
+	for _, seat := range result {
+		rows[seat.Row] += seat.Letter
+	}
+	kinds := make(map[string]map[string]Kind)
+	for _, letters := range rows {
+		if _, ok := kinds[letters]; ok {
+			continue
+		}
+		ref := letters
+		if !strings.Contains(seatLetters, letters) {
+			best := ""
+			for _, full := range rows {
+				if strings.Contains(seatLetters, full) && containsAll(full, letters) &&
+					(len(full) > len(best) || len(full) == len(best) && full < best) {
+					best = full
+				}
+			}
+			if best != "" {
+				ref = best
+			}
+		}
+		kinds[letters] = rowKinds(ref)
+	}
+	for i := range result {
+		result[i].Kind = kinds[rows[result[i].Row]][result[i].Letter]
+	}
+	return result, nil
+}
+
+// rowKinds размечает ряд с буквами letters (по порядку слева направо) по rowBlocks.
+func rowKinds(letters string) map[string]Kind {
+	blocks, ok := rowBlocks[len(letters)]
+	if !ok {
+		blocks = []int{len(letters)}
+	}
+	kinds := make(map[string]Kind, len(letters))
+	pos := 0
+	for b, size := range blocks {
+		for k := 0; k < size; k++ {
+			letter := letters[pos : pos+1]
+			switch {
+			case pos == 0 || pos == len(letters)-1:
+				kinds[letter] = Window
+			case (k == 0 && b > 0) || (k == size-1 && b < len(blocks)-1):
+				kinds[letter] = Aisle
+			default:
+				kinds[letter] = Middle
+			}
+			pos++
+		}
+	}
+	return kinds
+}
+
+func containsAll(full, letters string) bool {
+	for _, letter := range letters {
+		if !strings.ContainsRune(full, letter) {
+			return false
+		}
+	}
+	return true
+}
+
+var kindRank = map[Kind]int{Window: 0, Aisle: 1, Middle: 2}
+
+// Pick выбирает место из свободных детерминированно: сначала места типа prefer
+// (если задан), затем ближе к носу, затем у окна, у прохода и посередине, затем по букве.
+func Pick(free []Seat, prefer Kind) (Seat, bool) {
+	if len(free) == 0 {
+		return Seat{}, false
+	}
+	best := free[0]
+	for _, seat := range free[1:] {
+		if less(seat, best, prefer) {
+			best = seat
+		}
+	}
+	return best, true
+}
+
+func less(a, b Seat, prefer Kind) bool {
+	if prefer != "" && (a.Kind == prefer) != (b.Kind == prefer) {
+		return a.Kind == prefer
+	}
+	if a.Row != b.Row {
+		return a.Row < b.Row
+	}
+	if kindRank[a.Kind] != kindRank[b.Kind] {
+		return kindRank[a.Kind] < kindRank[b.Kind]
+	}
+	return a.Letter < b.Letter
+}
@@ -0,0 +1,196 @@
+package seats
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in     string
+		row    int
+		letter string
+		err    bool
+	}{
+		{in: "1A", row: 1, letter: "A"},
+		{in: "12K", row: 12, letter: "K"},
+		{in: "123C", row: 123, letter: "C"},
+		{in: "A", err: true},
+		{in: "12", err: true},
+		{in: "0A", err: true},
+		{in: "12a", err: true},
+		{in: "A1", err: true},
+	}
+	for _, tt := range tests {
+		row, letter, err := Parse(tt.in)
+		if tt.err {
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("Parse(%q) error = %v, want ErrInvalid", tt.in, err)
+			}
+			continue
+		}
+		if err != nil || row != tt.row || letter != tt.letter {
+			t.Errorf("Parse(%q) = %d, %q, %v; want %d, %q", tt.in, row, letter, err, tt.row, tt.letter)
+		}
+	}
+}
+
+// cabin возвращает номера мест рядов from..to с буквами letters.
+func cabin(from, to int, letters string) []string {
+	var seatNos []string
+	for row := from; row <= to; row++ {
+		for _, letter := range letters {
+			seatNos = append(seatNos, fmt.Sprintf("%d%c", row, letter))
+		}
+	}
+	return seatNos
+}
+
+func kinds(t *testing.T, seatNos []string) map[string]Kind {
+	t.Helper()
+	layout, err := Layout(seatNos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]Kind, len(layout))
+	for _, seat := range layout {
+		got[seat.No] = seat.Kind
+	}
+	return got
+}
+
+func TestLayout(t *testing.T) {
+	const (
+		w = Window
+		a = Aisle
+		m = Middle
+	)
+	tests := []struct {
+		name    string
+		seatNos []string
+		want    map[string]Kind
+	}{
+		{
+			name:    "2-2",
+			seatNos: cabin(1, 2, "ABCD"),
+			want:    map[string]Kind{"1A": w, "1B": a, "1C": a, "1D": w, "2A": w, "2D": w},
+		},
+		{
+			name:    "3-3",
+			seatNos: cabin(1, 1, "ABCDEF"),
+			want:    map[string]Kind{"1A": w, "1B": m, "1C": a, "1D": a, "1E": m, "1F": w},
+		},
+		{
+			name:    "3-4-3 without I",
+			seatNos: cabin(1, 1, "ABCDEFGHJK"),
+			want: map[string]Kind{
+				"1A": w, "1B": m, "1C": a,
+				"1D": a, "1E": m, "1F": m, "1G": a,
+				"1H": a, "1J": m, "1K": w,
+			},
+		},
+		{
+			name:    "business 2-2 with missing letters over economy 3-3",
+			seatNos: append(cabin(1, 2, "ACDF"), cabin(3, 4, "ABCDEF")...),
+			want: map[string]Kind{
+				"1A": w, "1C": a, "1D": a, "1F": w,
+				"3A": w, "3B": m, "3C": a, "3D": a, "3E": m, "3F": w,
+			},
+		},
+		{
+			name:    "3-3 row without one seat",
+			seatNos: append(cabin(10, 10, "ABCDF"), cabin(11, 11, "ABCDEF")...),
+			want:    map[string]Kind{"10A": w, "10B": m, "10C": a, "10D": a, "10F": w},
+		},
+		{
+			name:    "row with missing letters and no full row",
+			seatNos: cabin(1, 1, "ACDF"),
+			want:    map[string]Kind{"1A": w, "1C": a, "1D": a, "1F": w},
+		},
+		{
+			name:    "narrow business over wide economy keeps its own width",
+			seatNos: append(cabin(1, 1, "ABCD"), cabin(2, 2, "ABCDEF")...),
+			want:    map[string]Kind{"1A": w, "1B": a, "1C": a, "1D": w},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := kinds(t, tt.seatNos)
+			for no, want := range tt.want {
+				if got[no] != want {
+					t.Errorf("%s: kind = %q, want %q", no, got[no], want)
+				}
+			}
+		})
+	}
+}
+
+func TestLayoutOrder(t *testing.T) {
+	layout, err := Layout([]string{"10B", "2C", "10A", "2A", "1D"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, seat := range layout {
+		got = append(got, seat.No)
+	}
+	want := []string{"1D", "2A", "2C", "10A", "10B"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Layout() order = %v, want %v", got, want)
+	}
+
+	if _, err := Layout([]string{"1A", "X"}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Layout() with a bad seat error = %v, want ErrInvalid", err)
+	}
+}
+
+func TestPick(t *testing.T) {
+	layout, err := Layout(cabin(1, 3, "ABCDEF"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	free := func(seatNos ...string) []Seat {
+		wanted := make(map[string]bool, len(seatNos))
+		for _, no := range seatNos {
+			wanted[no] = true
+		}
+		var seats []Seat
+		for _, seat := range layout {
+			if wanted[seat.No] {
+				seats = append(seats, seat)
+			}
+		}
+		return seats
+	}
+
+	tests := []struct {
+		name   string
+		free   []Seat
+		prefer Kind
+		want   string
+	}{
+		{"front row first", free("3A", "2B", "2E"), "", "2B"},
+		{"window before aisle in a row", free("1C", "1F", "1D"), "", "1F"},
+		{"aisle before middle in a row", free("1B", "1D", "1E"), "", "1D"},
+		{"letter breaks ties", free("2F", "2A"), "", "2A"},
+		{"window preference beats the front row", free("1C", "3F"), Window, "3F"},
+		{"aisle preference beats the front row", free("1A", "2B", "3D"), Aisle, "3D"},
+		{"middle preference", free("1A", "1C", "2E"), Middle, "2E"},
+		{"preference that cannot be met", free("1B", "2E"), Window, "1B"},
+		{"whole cabin free", layout, "", "1A"},
+		{"whole cabin free, aisle", layout, Aisle, "1C"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Pick(tt.free, tt.prefer)
+			if !ok || got.No != tt.want {
+				t.Errorf("Pick() = %s, %v; want %s", got.No, ok, tt.want)
+			}
+		})
+	}
+
+	if _, ok := Pick(nil, Window); ok {
+		t.Error("Pick() without free seats = true, want false")
+	}
+}
@@ -0,0 +1,137 @@
+// Package ticketno выдаёт и проверяет 13-значные номера билетов в формате демо-базы:
+// трёхзначный префикс перевозчика, девятизначный серийный номер и контрольная цифра.
+package ticketno
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+const (
+	Length       = 13
+	prefixLength = 3
+	maxSerial    = 999_999_999
+	// Сколько раз SequenceGenerator пропускает номер, уже занятый старыми данными.
+	maxAttempts = 10
+)
+
+var ErrInvalid = errors.New("invalid ticket number")
+
+// Generator выдаёт номера билетов внутри транзакции бронирования.
+type Generator interface {
+	Next(tx *gorm.DB) (string, error)
+}
+
+// CheckDigit — остаток от деления префикса и серийного номера, как одного числа, на 7.
+// Схема похожа на контрольную цифру IATA по модулю 7, но, в отличие от неё, учитывает
+// и префикс перевозчика, поэтому с номерами других систем не совместима.
+func CheckDigit(prefix string, serial uint64) int {
+	n, _ := strconv.ParseUint(prefix, 10, 64)
+	return int((n*(maxSerial+1) + serial) % 7)
+}
+
+func Format(prefix string, serial uint64) string {
+	return fmt.Sprintf("%s%09d%d", prefix, serial, CheckDigit(prefix, serial))
+}
+
+// Validate проверяет формат номера. Контрольная цифра проверяется только для номеров
+// с префиксом prefix: билеты демо-базы выписаны по другим правилам.
+func Validate(ticketNo, prefix string) error {
+	if len(ticketNo) != Length {
+		return fmt.Errorf("%w: must be %d digits", ErrInvalid, Length)
+	}
+	for _, c := range ticketNo {
+		if c < '0' || c > '9' {
+			return fmt.Errorf("%w: must be %d digits", ErrInvalid, Length)
+		}
+	}
+	if ticketNo[:prefixLength] != prefix {
+		return nil
+	}
+
+	serial, _ := strconv.ParseUint(ticketNo[prefixLength:Length-1], 10, 64)
+	if int(ticketNo[Length-1]-'0') != CheckDigit(prefix, serial) {
+		return fmt.Errorf("%w: check digit mismatch", ErrInvalid)
+	}
+	return nil
+}
+
+func validPrefix(prefix string) error {
+	if len(prefix) != prefixLength {
+		return fmt.Errorf("ticket prefix must be %d digits, got %q", prefixLength, prefix)
+	}
+	if _, err := strconv.ParseUint(prefix, 10, 64); err != nil {
+		return fmt.Errorf("ticket prefix must be %d digits, got %q", prefixLength, prefix)
+	}
+	return nil
+}
+
+// SequenceGenerator берёт серийные номера из последовательности базы, поэтому номера
+// уникальны между экземплярами сервиса и после перезапуска.
+type SequenceGenerator struct {
+	Prefix   string
+	Sequence string
+}
+
+func NewSequenceGenerator(prefix, sequence string) (*SequenceGenerator, error) {
+	if err := validPrefix(prefix); err != nil {
+		return nil, err
+	}
+	return &SequenceGenerator{Prefix: prefix, Sequence: sequence}, nil
+}
+
+func (g *SequenceGenerator) Next(tx *gorm.DB) (string, error) {
+	for range maxAttempts {
+		var serial uint64
+		if err := tx.Raw("SELECT nextval(?::regclass)", g.Sequence).Scan(&serial).Error; err != nil {
+			return "", err
+		}
+		if serial > maxSerial {
+			return "", fmt.Errorf("ticket number sequence %s is exhausted", g.Sequence)
+		}
+
+		ticketNo := Format(g.Prefix, serial)
+		var taken int64
+		if err := tx.Raw(`
+            SELECT (SELECT COUNT(*) FROM ticket_flights WHERE ticket_no = @no)
+                 + (SELECT COUNT(*) FROM books WHERE ticket_no = @no)`,
+			map[string]interface{}{"no": ticketNo}).Scan(&taken).Error; err != nil {
+			return "", err
+		}
+		if taken == 0 {
+			return ticketNo, nil
+		}
+	}
+	return "", fmt.Errorf("no free ticket number after %d attempts", maxAttempts)
+}
+
+// Sequential выдаёт номера подряд из памяти, не обращаясь к базе. Предназначен для тестов
+// и локальных прогонов, где нужны предсказуемые номера.
+type Sequential struct {
+	Prefix string
+
+	mu   sync.Mutex
+	next uint64
+}
+
+func NewSequential(prefix string, start uint64) (*Sequential, error) {
+	if err := validPrefix(prefix); err != nil {
+		return nil, err
+	}
+	return &Sequential{Prefix: prefix, next: start}, nil
+}
+
+func (g *Sequential) Next(*gorm.DB) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.next > maxSerial {
+		return "", fmt.Errorf("sequential ticket numbers exhausted")
+	}
+	ticketNo := Format(g.Prefix, g.next)
+	g.next++
+	return ticketNo, nil
+}
@@ -0,0 +1,110 @@
+package ticketno
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		prefix string
+		serial uint64
+		want   int
+	}{
+		{"999", 1, 3},
+		{"999", 123456789, 3},
+		{"555", 0, 5},
+	}
+	for _, tt := range tests {
+		if got := CheckDigit(tt.prefix, tt.serial); got != tt.want {
+			t.Errorf("CheckDigit(%q, %d) = %d, want %d", tt.prefix, tt.serial, got, tt.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		prefix string
+		serial uint64
+		want   string
+	}{
+		{"999", 1, "9990000000013"},
+		{"999", 123456789, "9991234567893"},
+		{"555", 0, "5550000000005"},
+	}
+	for _, tt := range tests {
+		got := Format(tt.prefix, tt.serial)
+		if got != tt.want {
+			t.Errorf("Format(%q, %d) = %q, want %q", tt.prefix, tt.serial, got, tt.want)
+		}
+		if len(got) != Length {
+			t.Errorf("Format(%q, %d) has length %d, want %d", tt.prefix, tt.serial, len(got), Length)
+		}
+		if err := Validate(got, tt.prefix); err != nil {
+			t.Errorf("Validate(Format(%q, %d)) = %v", tt.prefix, tt.serial, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		ticketNo string
+		wantErr  bool
+	}{
+		{"own prefix", "9990000000013", false},
+		{"too short", "999000000001", true},
+		{"too long", "99900000000133", true},
+		{"non-digits", "99900000000A3", true},
+		{"bad check digit", "9990000000014", true},
+		// Билеты демо-базы выписаны по другим правилам: контрольная цифра не проверяется.
+		{"foreign prefix", "0005432000987", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.ticketNo, "999")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate(%q) = %v, wantErr %v", tt.ticketNo, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalid) {
+				t.Errorf("Validate(%q) = %v, want ErrInvalid", tt.ticketNo, err)
+			}
+		})
+	}
+}
+
+func TestSequential(t *testing.T) {
+	g, err := NewSequential("999", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"9990000000013", Format("999", 2), Format("999", 3)}
+	for i, w := range want {
+		got, err := g.Next(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != w {
+			t.Errorf("Next() #%d = %q, want %q", i+1, got, w)
+		}
+	}
+
+	last, err := NewSequential("999", maxSerial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := last.Next(nil); err != nil {
+		t.Fatalf("Next() at the last serial: %v", err)
+	}
+	if _, err := last.Next(nil); err == nil {
+		t.Error("Next() after the last serial succeeded, want an error")
+	}
+}
+
+func TestNewSequentialRejectsBadPrefix(t *testing.T) {
+	for _, prefix := range []string{"", "99", "9999", "9A9"} {
+		if _, err := NewSequential(prefix, 1); err == nil {
+			t.Errorf("NewSequential(%q) succeeded, want an error", prefix)
+		}
+	}
+}